@@ -0,0 +1,169 @@
+// Package levels defines the log levels shared by unilogger, wrappedslog and zap.
+package levels
+
+import (
+	"cmp"
+	"encoding"
+	"flag"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode"
+)
+
+var (
+	_ slog.Leveler             = Level(0)
+	_ encoding.TextMarshaler   = Level(0)
+	_ encoding.TextUnmarshaler = (*Level)(nil)
+	_ flag.Value               = (*Level)(nil)
+)
+
+type Level slog.Level
+
+const (
+	LevelTrace Level = -8
+	LevelDebug Level = -4
+	LevelInfo  Level = 0
+	LevelWarn  Level = 4
+	LevelError Level = 8
+	// LevelPanic is below LevelFatal, as in zap
+	LevelPanic Level = 11
+	LevelFatal Level = 12
+)
+
+type namedLevel struct {
+	name  string
+	level Level
+}
+
+// names are the built-in and the registered levels in ascending order,
+// replaced as a whole by Register
+var names atomic.Pointer[[]namedLevel]
+
+func init() {
+	names.Store(&[]namedLevel{
+		{"trace", LevelTrace},
+		{"debug", LevelDebug},
+		{"info", LevelInfo},
+		{"warn", LevelWarn},
+		{"error", LevelError},
+		{"panic", LevelPanic},
+		{"fatal", LevelFatal},
+	})
+}
+
+var registerMu sync.Mutex
+
+// Register adds a named level, like "notice" at 2. The level prints by
+// its name, parses by ParseLevel and the levels above it up to the next
+// named one print as offsets from it. Names and values are unique,
+// the built-in levels can not be replaced. Registering the same level
+// again does nothing.
+func Register(name string, level Level) error {
+	if name == "" || strings.ContainsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	}) {
+		return fmt.Errorf("register level %q: bad name", name)
+	}
+
+	registerMu.Lock()
+	defer registerMu.Unlock()
+
+	old := *names.Load()
+
+	for _, n := range old {
+		if strings.EqualFold(n.name, name) && n.level == level {
+			return nil
+		}
+
+		if strings.EqualFold(n.name, name) {
+			return fmt.Errorf("register level %q: name is taken", name)
+		}
+
+		if n.level == level {
+			return fmt.Errorf("register level %q: value %d is taken by %q", name, level, n.name)
+		}
+	}
+
+	updated := append(slices.Clone(old), namedLevel{name: strings.ToLower(name), level: level})
+	slices.SortFunc(updated, func(a, b namedLevel) int {
+		return cmp.Compare(a.level, b.level)
+	})
+
+	names.Store(&updated)
+
+	return nil
+}
+
+func (l Level) Level() slog.Level {
+	return slog.Level(l)
+}
+
+// String returns the name of the nearest level below l with the offset,
+// like "debug+2". Levels below trace are written as "trace-N".
+func (l Level) String() string {
+	names := *names.Load()
+	base := names[0]
+
+	for _, n := range names[1:] {
+		if n.level > l {
+			break
+		}
+
+		base = n
+	}
+
+	if l == base.level {
+		return base.name
+	}
+
+	return fmt.Sprintf("%s%+d", base.name, l-base.level)
+}
+
+// ParseLevel parses the output of Level.String, case-insensitive.
+func ParseLevel(s string) (Level, error) {
+	name, offset := s, 0
+
+	if i := strings.IndexAny(s, "+-"); i >= 0 {
+		var err error
+
+		name = s[:i]
+
+		offset, err = strconv.Atoi(s[i:])
+		if err != nil {
+			return LevelInfo, fmt.Errorf("level %q: bad offset: %w", s, err)
+		}
+	}
+
+	for _, n := range *names.Load() {
+		if strings.EqualFold(n.name, name) {
+			return n.level + Level(offset), nil
+		}
+	}
+
+	return LevelInfo, fmt.Errorf("level %q: unknown name %q", s, name)
+}
+
+func (l Level) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+func (l *Level) UnmarshalText(data []byte) error {
+	level, err := ParseLevel(string(data))
+	if err != nil {
+		return err
+	}
+
+	*l = level
+
+	return nil
+}
+
+// Set implements flag.Value.
+func (l *Level) Set(s string) error {
+	return l.UnmarshalText([]byte(s))
+}
@@ -0,0 +1,158 @@
+package levels_test
+
+import (
+	"encoding/json"
+	"flag"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+
+	"slog-test/levels"
+)
+
+func Test_Level_String(t *testing.T) {
+	t.Parallel()
+
+	type meta struct {
+		name    string
+		enabled bool
+	}
+
+	type args struct {
+		level levels.Level
+	}
+
+	type wants struct {
+		str string
+	}
+
+	tests := []struct {
+		meta  meta
+		args  args
+		wants wants
+	}{
+		{meta: meta{name: "trace", enabled: true}, args: args{level: levels.LevelTrace}, wants: wants{str: "trace"}},
+		{meta: meta{name: "below trace", enabled: true}, args: args{level: levels.LevelTrace - 2}, wants: wants{str: "trace-2"}},
+		{meta: meta{name: "debug offset", enabled: true}, args: args{level: levels.LevelDebug + 2}, wants: wants{str: "debug+2"}},
+		{meta: meta{name: "info", enabled: true}, args: args{level: levels.LevelInfo}, wants: wants{str: "info"}},
+		{meta: meta{name: "warn offset", enabled: true}, args: args{level: levels.LevelWarn + 3}, wants: wants{str: "warn+3"}},
+		{meta: meta{name: "error offset", enabled: true}, args: args{level: levels.LevelError + 1}, wants: wants{str: "error+1"}},
+		{meta: meta{name: "panic", enabled: true}, args: args{level: levels.LevelPanic}, wants: wants{str: "panic"}},
+		{meta: meta{name: "fatal offset", enabled: true}, args: args{level: levels.LevelFatal + 4}, wants: wants{str: "fatal+4"}},
+	}
+
+	for _, tt := range tests {
+		if !tt.meta.enabled {
+			continue
+		}
+
+		t.Run(tt.meta.name, func(t *testing.T) {
+			assert.Equal(t, tt.wants.str, tt.args.level.String())
+
+			level, err := levels.ParseLevel(tt.wants.str)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.args.level, level)
+		})
+	}
+}
+
+func Test_ParseLevel(t *testing.T) {
+	t.Parallel()
+
+	type meta struct {
+		name    string
+		enabled bool
+	}
+
+	type args struct {
+		str string
+	}
+
+	type wants struct {
+		level levels.Level
+		err   bool
+	}
+
+	tests := []struct {
+		meta  meta
+		args  args
+		wants wants
+	}{
+		{meta: meta{name: "upper case", enabled: true}, args: args{str: "WARN"}, wants: wants{level: levels.LevelWarn}},
+		{meta: meta{name: "offset across names", enabled: true}, args: args{str: "debug+6"}, wants: wants{level: levels.LevelInfo + 2}},
+		{meta: meta{name: "negative offset", enabled: true}, args: args{str: "error-1"}, wants: wants{level: levels.LevelWarn + 3}},
+		{meta: meta{name: "unknown name", enabled: true}, args: args{str: "verbose"}, wants: wants{level: levels.LevelInfo, err: true}},
+		{meta: meta{name: "bad offset", enabled: true}, args: args{str: "info+x"}, wants: wants{level: levels.LevelInfo, err: true}},
+		{meta: meta{name: "empty", enabled: true}, args: args{str: ""}, wants: wants{level: levels.LevelInfo, err: true}},
+	}
+
+	for _, tt := range tests {
+		if !tt.meta.enabled {
+			continue
+		}
+
+		t.Run(tt.meta.name, func(t *testing.T) {
+			level, err := levels.ParseLevel(tt.args.str)
+
+			assert.Equal(t, tt.wants.err, err != nil)
+			assert.Equal(t, tt.wants.level, level)
+		})
+	}
+}
+
+func Test_Level_Text(t *testing.T) {
+	t.Parallel()
+
+	var cfg struct {
+		Level levels.Level `json:"level"`
+	}
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"level":"debug+2"}`), &cfg))
+	assert.Equal(t, levels.LevelDebug+2, cfg.Level)
+
+	data, err := json.Marshal(cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"level":"debug+2"}`, string(data))
+
+	assert.Error(t, json.Unmarshal([]byte(`{"level":"loud"}`), &cfg))
+}
+
+func Test_Level_Flag(t *testing.T) {
+	t.Parallel()
+
+	level := levels.LevelInfo
+
+	fs := flag.NewFlagSet("stub", flag.ContinueOnError)
+	fs.Var(&level, "level", "log level")
+
+	assert.NoError(t, fs.Parse([]string{"-level", "trace"}))
+	assert.Equal(t, levels.LevelTrace, level)
+	assert.Equal(t, "trace", fs.Lookup("level").Value.String())
+}
+
+func Test_Register(t *testing.T) {
+	const (
+		levelNotice = levels.LevelInfo + 2
+		levelAudit  = levels.LevelError + 2
+	)
+
+	assert.NoError(t, levels.Register("notice", levelNotice))
+	assert.NoError(t, levels.Register("Audit", levelAudit))
+
+	assert.Equal(t, "notice", levelNotice.String())
+	assert.Equal(t, "notice+1", (levelNotice + 1).String())
+	assert.Equal(t, "audit", levelAudit.String())
+	assert.Equal(t, "panic", levels.LevelPanic.String())
+
+	level, err := levels.ParseLevel("AUDIT-1")
+	assert.NoError(t, err)
+	assert.Equal(t, levelAudit-1, level)
+
+	// the same level again, like in a second test run
+	assert.NoError(t, levels.Register("notice", levelNotice))
+	assert.EqualError(t, levels.Register("notice", levelNotice+1), `register level "notice": name is taken`)
+
+	assert.EqualError(t, levels.Register("info", 1), `register level "info": name is taken`)
+	assert.EqualError(t, levels.Register("loud", levels.LevelWarn), `register level "loud": value 4 is taken by "warn"`)
+	assert.EqualError(t, levels.Register("a+b", 5), `register level "a+b": bad name`)
+}
@@ -0,0 +1,128 @@
+package unilogger
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+)
+
+// max size of the level change request
+const maxLevelsBody = 64 << 10
+
+// LevelsState is the body of the level handler responses.
+type LevelsState struct {
+	Level Level `json:"level"`
+	// Loggers are the levels set for the names, see Logger.NamedLevels
+	Loggers map[string]Level `json:"loggers"`
+}
+
+// LevelsChange is the JSON body of the level handler PUT and POST requests.
+// A null logger level resets it, see Logger.ResetNamedLevel.
+type LevelsChange struct {
+	Level   *Level            `json:"level,omitempty"`
+	Loggers map[string]*Level `json:"loggers,omitempty"`
+}
+
+// LevelHandler returns a handler to view and change the levels of l and its
+// Named children at runtime, like zap.AtomicLevel.ServeHTTP.
+//
+// GET returns LevelsState. PUT and POST take LevelsChange with the
+// "application/json" content type and return the new LevelsState. A request
+// with any bad level changes nothing. Other content types are refused, so that
+// a cross-origin HTML form can not post a change.
+func (l *Logger) LevelHandler() http.Handler {
+	return levelHandler{l: l}
+}
+
+// LevelHandler is Logger.LevelHandler of the current default logger.
+func LevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Default().LevelHandler().ServeHTTP(w, r)
+	})
+}
+
+type levelHandler struct {
+	l *Logger
+}
+
+func (h levelHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut, http.MethodPost:
+		if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "application/json" {
+			writeLevelsJSON(w, http.StatusUnsupportedMediaType,
+				map[string]string{"error": "content type must be application/json"})
+
+			return
+		}
+
+		spec, err := readLevelsChange(w, r)
+		if err != nil {
+			writeLevelsJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+
+			return
+		}
+
+		h.l.ApplyLevelSpec(spec.LevelSpec)
+		for _, name := range spec.reset {
+			h.l.ResetNamedLevel(name)
+		}
+	default:
+		w.Header().Set("Allow", "GET, PUT, POST")
+		writeLevelsJSON(w, http.StatusMethodNotAllowed,
+			map[string]string{"error": fmt.Sprintf("method %s is not allowed", r.Method)})
+
+		return
+	}
+
+	writeLevelsJSON(w, http.StatusOK, LevelsState{
+		Level:   h.l.Level(),
+		Loggers: h.l.NamedLevels(),
+	})
+}
+
+// levelsSpec is a validated level change.
+type levelsSpec struct {
+	LevelSpec
+
+	reset []string
+}
+
+func readLevelsChange(w http.ResponseWriter, r *http.Request) (levelsSpec, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxLevelsBody)
+
+	var change LevelsChange
+
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(&change); err != nil {
+		return levelsSpec{}, fmt.Errorf("bad body: %w", err)
+	}
+
+	spec := levelsSpec{LevelSpec: LevelSpec{Root: change.Level}}
+
+	var errs []error
+
+	for name, level := range change.Loggers {
+		switch {
+		case !validLoggerName(name):
+			errs = append(errs, fmt.Errorf("bad logger name %q", name))
+		case level == nil:
+			spec.reset = append(spec.reset, name)
+		default:
+			spec.Named = append(spec.Named, NamedLevel{Name: name, Level: *level})
+		}
+	}
+
+	return spec, errors.Join(errs...)
+}
+
+func writeLevelsJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,176 @@
+package unilogger_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+
+	"slog-test/unilogger"
+)
+
+func Test_LevelHandler(t *testing.T) {
+	t.Parallel()
+
+	type meta struct {
+		name    string
+		enabled bool
+	}
+
+	type args struct {
+		method      string
+		contentType string
+		body        string
+	}
+
+	type wants struct {
+		status int
+		body   string
+	}
+
+	tests := []struct {
+		meta  meta
+		args  args
+		wants wants
+	}{
+		{
+			meta: meta{
+				name:    "get",
+				enabled: true,
+			},
+			args: args{method: http.MethodGet},
+			wants: wants{
+				status: http.StatusOK,
+				body:   `{"level":"info","loggers":{"db":"warn"}}`,
+			},
+		},
+		{
+			meta: meta{
+				name:    "put json",
+				enabled: true,
+			},
+			args: args{
+				method:      http.MethodPut,
+				contentType: "application/json",
+				body:        `{"level":"debug","loggers":{"db":null,"http.*":"error","http":"warn+1"}}`,
+			},
+			wants: wants{
+				status: http.StatusOK,
+				body:   `{"level":"debug","loggers":{"http":"warn+1"}}`,
+			},
+		},
+		{
+			meta: meta{
+				name:    "post json with charset",
+				enabled: true,
+			},
+			args: args{
+				method:      http.MethodPost,
+				contentType: "application/json; charset=utf-8",
+				body:        `{"level":"error","loggers":{"db.pool":"trace"}}`,
+			},
+			wants: wants{
+				status: http.StatusOK,
+				body:   `{"level":"error","loggers":{"db":"warn","db.pool":"trace"}}`,
+			},
+		},
+		{
+			meta: meta{
+				name:    "form refused",
+				enabled: true,
+			},
+			args: args{
+				method:      http.MethodPost,
+				contentType: "application/x-www-form-urlencoded",
+				body:        `spec=error,db.pool=trace`,
+			},
+			wants: wants{
+				status: http.StatusUnsupportedMediaType,
+				body:   `{"error":"content type must be application/json"}`,
+			},
+		},
+		{
+			meta: meta{
+				name:    "json without content type refused",
+				enabled: true,
+			},
+			args: args{
+				method: http.MethodPut,
+				body:   `{"level":"debug"}`,
+			},
+			wants: wants{
+				status: http.StatusUnsupportedMediaType,
+				body:   `{"error":"content type must be application/json"}`,
+			},
+		},
+		{
+			meta: meta{
+				name:    "bad level changes nothing",
+				enabled: true,
+			},
+			args: args{
+				method:      http.MethodPut,
+				contentType: "application/json",
+				body:        `{"level":"debug","loggers":{"db":"loud"}}`,
+			},
+			wants: wants{
+				status: http.StatusBadRequest,
+				body:   `{"error":"bad body: level \"loud\": unknown name \"loud\""}`,
+			},
+		},
+		{
+			meta: meta{
+				name:    "bad logger name",
+				enabled: true,
+			},
+			args: args{
+				method:      http.MethodPut,
+				contentType: "application/json",
+				body:        `{"loggers":{"db..pool":"info"}}`,
+			},
+			wants: wants{
+				status: http.StatusBadRequest,
+				body:   `{"error":"bad logger name \"db..pool\""}`,
+			},
+		},
+		{
+			meta: meta{
+				name:    "method not allowed",
+				enabled: true,
+			},
+			args: args{method: http.MethodDelete},
+			wants: wants{
+				status: http.StatusMethodNotAllowed,
+				body:   `{"error":"method DELETE is not allowed"}`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		if !tt.meta.enabled {
+			continue
+		}
+
+		t.Run(tt.meta.name, func(t *testing.T) {
+			root := unilogger.NewLogger(unilogger.Options{Output: bytes.NewBuffer([]byte{})})
+			root.Named("db").Named("pool")
+			root.Named("http")
+			root.SetNamedLevel("db", unilogger.LevelWarn)
+
+			req := httptest.NewRequest(tt.args.method, "/log/level", strings.NewReader(tt.args.body))
+			if tt.args.contentType != "" {
+				req.Header.Set("Content-Type", tt.args.contentType)
+			}
+
+			rec := httptest.NewRecorder()
+			root.LevelHandler().ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wants.status, rec.Code)
+			assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+			assert.Equal(t, tt.wants.body+"\n", rec.Body.String())
+		})
+	}
+}
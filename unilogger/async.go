@@ -0,0 +1,305 @@
+package unilogger
+
+import (
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+)
+
+// AsyncPolicy decides what happens to a record when the async queue is full.
+type AsyncPolicy int
+
+const (
+	// AsyncBlock waits for the writer to make room
+	AsyncBlock AsyncPolicy = iota
+	// AsyncDropNewest drops the record being logged
+	AsyncDropNewest
+	// AsyncDropOldest drops the oldest queued record
+	AsyncDropOldest
+	// AsyncDropBelow drops the records below AsyncOptions.DropBelow
+	// and waits with the others
+	AsyncDropBelow
+)
+
+const (
+	DefaultAsyncQueueSize = 1024
+	DefaultAsyncBatchSize = 64 << 10
+)
+
+// AsyncOptions configure the asynchronous output.
+type AsyncOptions struct {
+	// QueueSize is the number of queued records, DefaultAsyncQueueSize if zero
+	QueueSize int
+	// BatchSize is the size of the coalesced writes in bytes,
+	// DefaultAsyncBatchSize if zero
+	BatchSize int
+	// Policy when the queue is full
+	Policy AsyncPolicy
+	// DropBelow is the level kept by AsyncDropBelow
+	DropBelow Level
+}
+
+var errAsyncClosed = errors.New("async output is closed")
+
+type asyncEntry struct {
+	buf   *buffer
+	level slog.Level
+}
+
+// asyncQueue is a bounded ring of rendered records written in batches by
+// a background goroutine.
+type asyncQueue struct {
+	opts AsyncOptions
+	out  *output
+
+	mu   sync.Mutex
+	cond *sync.Cond
+	ring []asyncEntry
+	head int
+	n    int
+	// the writer holds a batch taken from the ring
+	writing bool
+	closed  bool
+	// first write error since the last flush
+	err error
+
+	dropped atomic.Uint64
+	done    chan struct{}
+}
+
+func newAsyncQueue(out *output, opts AsyncOptions) *asyncQueue {
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = DefaultAsyncQueueSize
+	}
+
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultAsyncBatchSize
+	}
+
+	q := &asyncQueue{
+		opts: opts,
+		out:  out,
+		ring: make([]asyncEntry, opts.QueueSize),
+		done: make(chan struct{}),
+	}
+	q.cond = sync.NewCond(&q.mu)
+
+	go q.run()
+
+	return q
+}
+
+// push queues a copy of the record. It reports false when the queue is
+// closed and the record should be written synchronously.
+func (q *asyncQueue) push(p []byte, level slog.Level) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for !q.closed && q.n == len(q.ring) {
+		switch {
+		case q.opts.Policy == AsyncDropNewest,
+			q.opts.Policy == AsyncDropBelow && level < q.opts.DropBelow.Level():
+			q.dropped.Add(1)
+
+			return true
+		case q.opts.Policy == AsyncDropOldest:
+			q.ring[q.head].buf.Free()
+			q.ring[q.head] = asyncEntry{}
+			q.head = (q.head + 1) % len(q.ring)
+			q.n--
+			q.dropped.Add(1)
+		default:
+			q.cond.Wait()
+		}
+	}
+
+	if q.closed {
+		return false
+	}
+
+	buf := newBuffer()
+	*buf = append(*buf, p...)
+
+	q.ring[(q.head+q.n)%len(q.ring)] = asyncEntry{buf: buf, level: level}
+	q.n++
+	q.cond.Broadcast()
+
+	return true
+}
+
+func (q *asyncQueue) run() {
+	defer close(q.done)
+
+	batch := make([]byte, 0, q.opts.BatchSize)
+
+	for {
+		q.mu.Lock()
+
+		for q.n == 0 && !q.closed {
+			q.cond.Wait()
+		}
+
+		if q.n == 0 {
+			q.mu.Unlock()
+
+			return
+		}
+
+		// coalesce the queued records up to the batch size, at least one
+		batch = batch[:0]
+
+		for q.n > 0 {
+			e := q.ring[q.head]
+			if len(batch) > 0 && len(batch)+len(*e.buf) > q.opts.BatchSize {
+				break
+			}
+
+			batch = append(batch, *e.buf...)
+			e.buf.Free()
+
+			q.ring[q.head] = asyncEntry{}
+			q.head = (q.head + 1) % len(q.ring)
+			q.n--
+		}
+
+		q.writing = true
+		q.cond.Broadcast()
+		q.mu.Unlock()
+
+		err := q.out.writeSync(batch)
+
+		q.mu.Lock()
+		q.writing = false
+
+		if err != nil && q.err == nil {
+			q.err = err
+		}
+
+		q.cond.Broadcast()
+		q.mu.Unlock()
+
+		if cap(batch) > 4*q.opts.BatchSize {
+			batch = make([]byte, 0, q.opts.BatchSize)
+		}
+	}
+}
+
+// flush waits for the queued records to be written and returns the first
+// write error since the previous flush.
+func (q *asyncQueue) flush() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.waitLocked()
+
+	err := q.err
+	q.err = nil
+
+	return err
+}
+
+// wait waits for the queued records to be written.
+func (q *asyncQueue) wait() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.waitLocked()
+}
+
+func (q *asyncQueue) waitLocked() {
+	for q.n > 0 || q.writing {
+		q.cond.Wait()
+	}
+}
+
+// close writes the queued records and stops the writer.
+func (q *asyncQueue) close() error {
+	q.mu.Lock()
+	closed := q.closed
+	q.closed = true
+	q.cond.Broadcast()
+	q.mu.Unlock()
+
+	if closed {
+		return errAsyncClosed
+	}
+
+	<-q.done
+
+	return q.flush()
+}
+
+// WithAsync returns a handler which queues the records and writes them in
+// batches in the background, so slow writers don't stall the logging
+// goroutines. The handlers derived from it share the queue.
+func (h *SlogHandler) WithAsync(opts AsyncOptions) *SlogHandler {
+	h2 := h.clone()
+	h2.out = &output{w: h.out.writer()}
+	h2.out.async = newAsyncQueue(h2.out, opts)
+
+	return h2
+}
+
+// Flush waits until the queued records are written. It returns the first
+// write error of the queued records since the previous Flush.
+func (h *SlogHandler) Flush() error {
+	if len(h.sinks) > 0 {
+		return h.eachSink((*SlogHandler).Flush)
+	}
+
+	if h.out.async == nil {
+		return nil
+	}
+
+	return h.out.async.flush()
+}
+
+// Sync flushes the queue and syncs the writer if it has a Sync method,
+// like *os.File.
+func (h *SlogHandler) Sync() error {
+	if len(h.sinks) > 0 {
+		return h.eachSink((*SlogHandler).Sync)
+	}
+
+	err := h.Flush()
+
+	if s, ok := h.out.writer().(interface{ Sync() error }); ok {
+		err = errors.Join(err, s.Sync())
+	}
+
+	return err
+}
+
+// Close flushes the queue and stops the background writer, the records
+// logged afterwards are written synchronously. The writer is not closed.
+func (h *SlogHandler) Close() error {
+	if len(h.sinks) > 0 {
+		return h.eachSink((*SlogHandler).Close)
+	}
+
+	if h.out.async == nil {
+		return nil
+	}
+
+	return h.out.async.close()
+}
+
+// DroppedRecords returns the number of records dropped by the async policy.
+func (h *SlogHandler) DroppedRecords() uint64 {
+	if len(h.sinks) > 0 {
+		var dropped uint64
+
+		for _, sink := range h.sinks {
+			dropped += sink.DroppedRecords()
+		}
+
+		return dropped
+	}
+
+	if h.out.async == nil {
+		return 0
+	}
+
+	return h.out.async.dropped.Load()
+}
@@ -0,0 +1,308 @@
+package unilogger_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/assert/v2"
+
+	"slog-test/unilogger"
+)
+
+// gateWriter blocks its first write until released.
+type gateWriter struct {
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	writes  int
+	once    sync.Once
+	entered chan struct{}
+	release chan struct{}
+}
+
+func newGateWriter() *gateWriter {
+	return &gateWriter{
+		entered: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+}
+
+func (w *gateWriter) Write(p []byte) (int, error) {
+	w.once.Do(func() {
+		close(w.entered)
+		<-w.release
+	})
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.writes++
+
+	return w.buf.Write(p)
+}
+
+// msgs returns the messages of the written logfmt records.
+func (w *gateWriter) msgs() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var msgs []string
+
+	for _, line := range strings.Split(strings.TrimSpace(w.buf.String()), "\n") {
+		if _, msg, ok := strings.Cut(line, "msg="); ok {
+			msg, _, _ = strings.Cut(msg, " ")
+			msgs = append(msgs, msg)
+		}
+	}
+
+	return msgs
+}
+
+func Test_Async_Policies(t *testing.T) {
+	t.Parallel()
+
+	type meta struct {
+		name    string
+		enabled bool
+	}
+
+	type args struct {
+		opts unilogger.AsyncOptions
+	}
+
+	type wants struct {
+		msgs    []string
+		dropped uint64
+		writes  int
+	}
+
+	tests := []struct {
+		meta  meta
+		args  args
+		wants wants
+	}{
+		{
+			meta: meta{
+				name:    "drop newest",
+				enabled: true,
+			},
+			args: args{
+				opts: unilogger.AsyncOptions{QueueSize: 2, Policy: unilogger.AsyncDropNewest},
+			},
+			wants: wants{
+				msgs:    []string{"first", "second", "third"},
+				dropped: 1,
+				writes:  2,
+			},
+		},
+		{
+			meta: meta{
+				name:    "drop oldest",
+				enabled: true,
+			},
+			args: args{
+				opts: unilogger.AsyncOptions{QueueSize: 2, Policy: unilogger.AsyncDropOldest},
+			},
+			wants: wants{
+				msgs:    []string{"first", "third", "fourth"},
+				dropped: 1,
+				writes:  2,
+			},
+		},
+		{
+			meta: meta{
+				name:    "drop below warn",
+				enabled: true,
+			},
+			args: args{
+				opts: unilogger.AsyncOptions{QueueSize: 2, Policy: unilogger.AsyncDropBelow, DropBelow: unilogger.LevelWarn},
+			},
+			wants: wants{
+				msgs:    []string{"first", "second", "third"},
+				dropped: 1,
+				writes:  2,
+			},
+		},
+		{
+			meta: meta{
+				name:    "small batches",
+				enabled: true,
+			},
+			args: args{
+				opts: unilogger.AsyncOptions{QueueSize: 3, BatchSize: 1},
+			},
+			wants: wants{
+				msgs:   []string{"first", "second", "third", "fourth"},
+				writes: 4,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		if !tt.meta.enabled {
+			continue
+		}
+
+		t.Run(tt.meta.name, func(t *testing.T) {
+			t.Parallel()
+
+			w := newGateWriter()
+
+			logger := unilogger.NewLogger(unilogger.Options{
+				Format: unilogger.FormatLogfmt,
+				Output: w,
+				Async:  &tt.args.opts,
+			})
+
+			logger.Info("first")
+			// the writer holds the first record, the others wait in the queue
+			<-w.entered
+
+			logger.Info("second")
+			logger.Info("third")
+			logger.Named("db").Info("fourth")
+
+			close(w.release)
+
+			assert.NoError(t, logger.Flush())
+			assert.Equal(t, tt.wants.msgs, w.msgs())
+			assert.Equal(t, tt.wants.dropped, logger.DroppedRecords())
+			assert.Equal(t, tt.wants.writes, w.writes)
+			assert.NoError(t, logger.Close())
+		})
+	}
+}
+
+func Test_Async_Block(t *testing.T) {
+	t.Parallel()
+
+	const (
+		goroutines = 8
+		records    = 200
+	)
+
+	w := newGateWriter()
+
+	root := unilogger.NewLogger(unilogger.Options{
+		Format: unilogger.FormatLogfmt,
+		Output: w,
+		Async:  &unilogger.AsyncOptions{QueueSize: 8},
+	})
+
+	var wg sync.WaitGroup
+
+	for i := range goroutines {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			logger := root.With("goroutine", i)
+			for range records {
+				logger.Info("stub")
+			}
+		}()
+	}
+
+	<-w.entered
+	close(w.release)
+	wg.Wait()
+
+	assert.NoError(t, root.Close())
+	assert.Equal(t, goroutines*records, len(w.msgs()))
+	assert.Equal(t, uint64(0), root.DroppedRecords())
+	assert.True(t, w.writes < goroutines*records)
+
+	// after Close the records are written synchronously
+	root.Info("closed")
+	assert.Equal(t, goroutines*records+1, len(w.msgs()))
+	assert.Error(t, root.Close())
+}
+
+type errWriter struct{}
+
+func (errWriter) Write([]byte) (int, error) {
+	return 0, errors.New("stub error")
+}
+
+func Test_Async_WriteError(t *testing.T) {
+	t.Parallel()
+
+	logger := unilogger.NewLogger(unilogger.Options{
+		Output: errWriter{},
+		Async:  &unilogger.AsyncOptions{},
+	})
+
+	logger.Info("stub")
+
+	assert.EqualError(t, logger.Flush(), "stub error")
+	assert.NoError(t, logger.Flush())
+	assert.NoError(t, logger.Close())
+}
+
+// slowWriter delays the writes to stdout, so the records stay queued.
+type slowWriter struct{}
+
+func (slowWriter) Write(p []byte) (int, error) {
+	time.Sleep(20 * time.Millisecond)
+
+	return os.Stdout.Write(p)
+}
+
+func Test_Async_Fatal(t *testing.T) {
+	t.Parallel()
+
+	const envFatal = "UNILOGGER_TEST_FATAL"
+
+	fatals := map[string]func(logger *unilogger.Logger){
+		"logger": func(logger *unilogger.Logger) {
+			logger.Fatal("bye")
+		},
+		"global": func(logger *unilogger.Logger) {
+			unilogger.SetDefault(logger)
+			unilogger.Fatal("bye")
+		},
+	}
+
+	// the subprocess logs and exits
+	if name := os.Getenv(envFatal); name != "" {
+		logger := unilogger.NewLogger(unilogger.Options{
+			Format:   unilogger.FormatLogfmt,
+			Output:   slowWriter{},
+			Async:    &unilogger.AsyncOptions{},
+			TimeFunc: stubTimeFn,
+		})
+
+		logger.Info("one")
+		logger.Info("two")
+		fatals[name](logger)
+
+		return
+	}
+
+	for name := range fatals {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cmd := exec.Command(os.Args[0], "-test.run=^Test_Async_Fatal$")
+			cmd.Env = append(os.Environ(), envFatal+"="+name)
+
+			out, err := cmd.Output()
+
+			var exitErr *exec.ExitError
+			assert.True(t, errors.As(err, &exitErr), "%v", err)
+			assert.Equal(t, 1, exitErr.ExitCode())
+
+			lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+			assert.Equal(t, 3, len(lines), "%s", out)
+			assert.Equal(t, "level=info msg=one time=2006-01-02T15:04:05Z", lines[0])
+			assert.Equal(t, "level=info msg=two time=2006-01-02T15:04:05Z", lines[1])
+			assert.True(t, strings.HasPrefix(lines[2], "level=fatal msg=bye trace="), "%s", lines[2])
+		})
+	}
+}
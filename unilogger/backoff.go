@@ -0,0 +1,49 @@
+package unilogger
+
+import "time"
+
+const (
+	DefaultMinBackoff = 100 * time.Millisecond
+	DefaultMaxBackoff = 30 * time.Second
+)
+
+// backoff spaces the reconnects out exponentially, between min and max.
+type backoff struct {
+	min, max time.Duration
+
+	cur  time.Duration
+	next time.Time
+}
+
+func newBackoff(minDelay, maxDelay time.Duration) backoff {
+	if minDelay <= 0 {
+		minDelay = DefaultMinBackoff
+	}
+
+	if maxDelay < minDelay {
+		maxDelay = max(DefaultMaxBackoff, minDelay)
+	}
+
+	return backoff{min: minDelay, max: maxDelay}
+}
+
+// ready reports whether the next attempt is due.
+func (b *backoff) ready(now time.Time) bool {
+	return !now.Before(b.next)
+}
+
+// fail doubles the delay of the next attempt.
+func (b *backoff) fail(now time.Time) {
+	if b.cur == 0 {
+		b.cur = b.min
+	} else {
+		b.cur = min(2*b.cur, b.max)
+	}
+
+	b.next = now.Add(b.cur)
+}
+
+func (b *backoff) reset() {
+	b.cur = 0
+	b.next = time.Time{}
+}
@@ -0,0 +1,157 @@
+package unilogger
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	colorReset   = "\x1b[0m"
+	colorBold    = "\x1b[1m"
+	colorDim     = "\x1b[2m"
+	colorRed     = "\x1b[31m"
+	colorGreen   = "\x1b[32m"
+	colorYellow  = "\x1b[33m"
+	colorCyan    = "\x1b[36m"
+	colorGray    = "\x1b[90m"
+	colorBoldRed = "\x1b[1;31m"
+)
+
+// width of the level tag, so the messages are aligned
+const levelTagWidth = 5
+
+// NewConsoleHandler returns a handler which writes human-readable lines:
+//
+//	2006-01-02T15:04:05Z INFO  logger msg key=value source=file.go:1
+//
+// followed by the trace, if any. Lines are colored when out is a terminal
+// and NO_COLOR is not set. Layout does not apply to this format.
+func NewConsoleHandler(out io.Writer, opts *slog.HandlerOptions, timeFn func(t time.Time) time.Time) *SlogHandler {
+	h := NewHandler(out, opts, timeFn)
+	h.format = FormatConsole
+	h.color = isTerminal(out) && os.Getenv("NO_COLOR") == ""
+
+	return h
+}
+
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+
+	fi, err := f.Stat()
+
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+func levelColor(l Level) string {
+	if colors := levelColors.Load(); colors != nil {
+		if color, ok := (*colors)[l]; ok {
+			return color
+		}
+	}
+
+	switch {
+	case l < LevelDebug:
+		return colorGray
+	case l < LevelInfo:
+		return colorCyan
+	case l < LevelWarn:
+		return colorGreen
+	case l < LevelError:
+		return colorYellow
+	case l < LevelPanic:
+		return colorRed
+	default:
+		return colorBoldRed
+	}
+}
+
+func levelTag(l Level) string {
+	switch l {
+	case LevelTrace:
+		return "TRACE"
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARN"
+	case LevelError:
+		return "ERROR"
+	case LevelPanic:
+		return "PANIC"
+	case LevelFatal:
+		return "FATAL"
+	default:
+		return strings.ToUpper(l.String())
+	}
+}
+
+// setColor starts the colored part of the line, if colors are on.
+func (s *handleState) setColor(color string) {
+	if s.h.color {
+		*s.buf = append(*s.buf, color...)
+	}
+}
+
+func (s *handleState) resetColor() {
+	if s.h.color {
+		*s.buf = append(*s.buf, colorReset...)
+	}
+}
+
+func (s *handleState) appendConsoleRecord() {
+	r := &s.record
+
+	s.setColor(colorDim)
+	s.appendTime(r.Time)
+	s.resetColor()
+
+	lvl := Level(r.Level)
+	tag := levelTag(lvl)
+
+	*s.buf = append(*s.buf, ' ')
+	s.setColor(levelColor(lvl))
+	*s.buf = append(*s.buf, tag...)
+	s.resetColor()
+
+	for i := len(tag); i < levelTagWidth; i++ {
+		*s.buf = append(*s.buf, ' ')
+	}
+
+	// if logger was named
+	if s.h.name != "" {
+		*s.buf = append(*s.buf, ' ')
+		s.setColor(colorBold)
+		*s.buf = append(*s.buf, s.h.name...)
+		s.resetColor()
+	}
+
+	*s.buf = append(*s.buf, ' ')
+	*s.buf = append(*s.buf, r.Message...)
+
+	s.appendBody()
+
+	if s.addSource() {
+		s.appendSource(slog.SourceKey, r.PC)
+	}
+
+	if len(s.trace) > 0 {
+		for _, f := range s.h.stackOpts.frames(s.trace) {
+			*s.buf = append(*s.buf, "\n\t"...)
+			*s.buf = append(*s.buf, f.Function...)
+			*s.buf = append(*s.buf, "\n\t\t"...)
+			*s.buf = append(*s.buf, f.File...)
+			*s.buf = append(*s.buf, ':')
+			*s.buf = strconv.AppendInt(*s.buf, int64(f.Line), 10)
+		}
+	}
+
+	*s.buf = append(*s.buf, '\n')
+}
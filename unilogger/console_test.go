@@ -0,0 +1,90 @@
+package unilogger_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+
+	"slog-test/unilogger"
+)
+
+func Test_ConsoleHandler(t *testing.T) {
+	t.Parallel()
+
+	type meta struct {
+		name    string
+		enabled bool
+	}
+
+	type fields struct {
+		logfn func(logger *unilogger.Logger)
+	}
+
+	type wants struct {
+		output string
+	}
+
+	tests := []struct {
+		meta   meta
+		fields fields
+		wants  wants
+	}{
+		{
+			meta: meta{
+				name:    "level tags are aligned",
+				enabled: true,
+			},
+			fields: fields{
+				logfn: func(logger *unilogger.Logger) {
+					logger.Info("stub msg")
+					logger.Error("stub msg")
+					logger.Log(context.Background(), slog.LevelInfo+1, "stub msg")
+				},
+			},
+			wants: wants{
+				output: "" +
+					"2006-01-02T15:04:05Z INFO  stub msg\n" +
+					"2006-01-02T15:04:05Z ERROR stub msg\n" +
+					"2006-01-02T15:04:05Z INFO+1 stub msg\n",
+			},
+		},
+		{
+			meta: meta{
+				name:    "named logger with flattened groups",
+				enabled: true,
+			},
+			fields: fields{
+				logfn: func(logger *unilogger.Logger) {
+					logger.Named("first").Named("second").With("a", 1).WithGroup("group").
+						Warn("stub msg", "b", "two words", slog.Group("inner", "c", true))
+				},
+			},
+			wants: wants{
+				output: `2006-01-02T15:04:05Z WARN  first.second stub msg a=1 group.b="two words" group.inner.c=true` + "\n",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		if !tt.meta.enabled {
+			continue
+		}
+
+		t.Run(tt.meta.name, func(t *testing.T) {
+			buf := bytes.NewBuffer([]byte{})
+
+			logger := unilogger.NewLogger(unilogger.Options{
+				Format:   unilogger.FormatConsole,
+				Output:   buf,
+				TimeFunc: stubTimeFn,
+			})
+
+			tt.fields.logfn(logger)
+
+			assert.Equal(t, tt.wants.output, buf.String())
+		})
+	}
+}
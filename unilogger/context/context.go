@@ -2,6 +2,7 @@ package slog
 
 import (
 	"context"
+	"log/slog"
 )
 
 type ctxKey string
@@ -22,15 +23,46 @@ func GetCustomKeyContext(ctx context.Context) bool {
 	return has
 }
 
-func SetStackTraceContext(ctx context.Context, trace string) context.Context {
+// SetStackTraceContext stores the program counters of the stack trace,
+// as returned by runtime.Callers.
+func SetStackTraceContext(ctx context.Context, trace []uintptr) context.Context {
 	return context.WithValue(ctx, stackTrace, trace)
 }
 
-func GetStackTraceContext(ctx context.Context) *string {
-	trace, ok := ctx.Value(stackTrace).(string)
+func GetStackTraceContext(ctx context.Context) []uintptr {
+	trace, ok := ctx.Value(stackTrace).([]uintptr)
 	if !ok {
 		return nil
 	}
 
-	return &trace
+	return trace
+}
+
+const minLevel ctxKey = "min_level"
+
+// SetLevelContext stores the minimum level of the records logged with ctx.
+func SetLevelContext(ctx context.Context, level slog.Level) context.Context {
+	return context.WithValue(ctx, minLevel, level)
+}
+
+func GetLevelContext(ctx context.Context) (slog.Level, bool) {
+	level, ok := ctx.Value(minLevel).(slog.Level)
+
+	return level, ok
+}
+
+const ruleAttrs ctxKey = "rule_attrs"
+
+// SetRuleAttrsContext stores the attributes matched by the level rules.
+func SetRuleAttrsContext(ctx context.Context, attrs []slog.Attr) context.Context {
+	return context.WithValue(ctx, ruleAttrs, attrs)
+}
+
+func GetRuleAttrsContext(ctx context.Context) []slog.Attr {
+	attrs, ok := ctx.Value(ruleAttrs).([]slog.Attr)
+	if !ok {
+		return nil
+	}
+
+	return attrs
 }
@@ -0,0 +1,113 @@
+package unilogger
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// EnvLogLevel is the variable read by ConfigureFromEnv.
+const EnvLogLevel = "LOG_LEVEL"
+
+// LevelSpec is a parsed level spec like "info,db=debug,http.*=warn".
+type LevelSpec struct {
+	// Root is the level of the entry without a name, if any
+	Root *Level
+	// Named are the levels of the names and the patterns, in the spec order
+	Named []NamedLevel
+}
+
+type NamedLevel struct {
+	Name  string
+	Level Level
+}
+
+// ParseLevelSpec parses comma-separated entries: a bare level sets the root
+// level, and name=level sets the level of a named logger or a pattern,
+// see Logger.SetNamedLevel. All bad entries are reported.
+func ParseLevelSpec(spec string) (LevelSpec, error) {
+	var (
+		ls   LevelSpec
+		errs []error
+	)
+
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, rawLevel, named := strings.Cut(entry, "=")
+		name, rawLevel = strings.TrimSpace(name), strings.TrimSpace(rawLevel)
+
+		if !named {
+			rawLevel = name
+		}
+
+		level, err := ParseLevel(rawLevel)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("entry %q: %w", entry, err))
+
+			continue
+		}
+
+		switch {
+		case !named && ls.Root != nil:
+			errs = append(errs, fmt.Errorf("entry %q: root level is already set", entry))
+		case !named:
+			ls.Root = &level
+		case !validLoggerName(name):
+			errs = append(errs, fmt.Errorf("entry %q: bad logger name %q", entry, name))
+		default:
+			ls.Named = append(ls.Named, NamedLevel{Name: name, Level: level})
+		}
+	}
+
+	return ls, errors.Join(errs...)
+}
+
+// validLoggerName reports whether name is a dotted name or a pattern.
+func validLoggerName(name string) bool {
+	if base, ok := splitPattern(name); ok {
+		return base == "" || validLoggerName(base)
+	}
+
+	for _, part := range strings.Split(name, ".") {
+		if part == "" || strings.ContainsAny(part, "*= \t") {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ApplyLevelSpec sets the root level and the levels of the named loggers.
+func (l *Logger) ApplyLevelSpec(spec LevelSpec) {
+	if spec.Root != nil {
+		l.SetLevel(*spec.Root)
+	}
+
+	for _, nl := range spec.Named {
+		l.SetNamedLevel(nl.Name, nl.Level)
+	}
+}
+
+// ConfigureFromEnv applies the level spec from LOG_LEVEL to the default logger
+// and its Named children. Nothing is changed if the variable is empty
+// or the spec has errors.
+func ConfigureFromEnv() error {
+	spec := os.Getenv(EnvLogLevel)
+	if spec == "" {
+		return nil
+	}
+
+	ls, err := ParseLevelSpec(spec)
+	if err != nil {
+		return fmt.Errorf("%s: %w", EnvLogLevel, err)
+	}
+
+	Default().ApplyLevelSpec(ls)
+
+	return nil
+}
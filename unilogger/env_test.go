@@ -0,0 +1,142 @@
+package unilogger_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+
+	"slog-test/unilogger"
+)
+
+func Test_ParseLevelSpec(t *testing.T) {
+	t.Parallel()
+
+	type meta struct {
+		name    string
+		enabled bool
+	}
+
+	type args struct {
+		spec string
+	}
+
+	type wants struct {
+		spec unilogger.LevelSpec
+		err  string
+	}
+
+	levelPtr := func(l unilogger.Level) *unilogger.Level {
+		return &l
+	}
+
+	tests := []struct {
+		meta  meta
+		args  args
+		wants wants
+	}{
+		{
+			meta: meta{
+				name:    "root and named levels",
+				enabled: true,
+			},
+			args: args{spec: "info,db=debug,http.*=warn"},
+			wants: wants{
+				spec: unilogger.LevelSpec{
+					Root: levelPtr(unilogger.LevelInfo),
+					Named: []unilogger.NamedLevel{
+						{Name: "db", Level: unilogger.LevelDebug},
+						{Name: "http.*", Level: unilogger.LevelWarn},
+					},
+				},
+			},
+		},
+		{
+			meta: meta{
+				name:    "spaces, empty entries and offsets",
+				enabled: true,
+			},
+			args: args{spec: " db.pool = TRACE , , *=error+1 "},
+			wants: wants{
+				spec: unilogger.LevelSpec{
+					Named: []unilogger.NamedLevel{
+						{Name: "db.pool", Level: unilogger.LevelTrace},
+						{Name: "*", Level: unilogger.LevelError + 1},
+					},
+				},
+			},
+		},
+		{
+			meta: meta{
+				name:    "every bad entry is reported",
+				enabled: true,
+			},
+			args: args{spec: "info,db=loud,warn,=debug,a..b=info"},
+			wants: wants{
+				spec: unilogger.LevelSpec{
+					Root: levelPtr(unilogger.LevelInfo),
+				},
+				err: `entry "db=loud": level "loud": unknown name "loud"` + "\n" +
+					`entry "warn": root level is already set` + "\n" +
+					`entry "=debug": bad logger name ""` + "\n" +
+					`entry "a..b=info": bad logger name "a..b"`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		if !tt.meta.enabled {
+			continue
+		}
+
+		t.Run(tt.meta.name, func(t *testing.T) {
+			spec, err := unilogger.ParseLevelSpec(tt.args.spec)
+
+			if tt.wants.err != "" {
+				assert.EqualError(t, err, tt.wants.err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.wants.spec, spec)
+		})
+	}
+}
+
+func Test_ConfigureFromEnv(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{})
+
+	prev := unilogger.Default()
+	t.Cleanup(func() { unilogger.SetDefault(prev) })
+
+	root := unilogger.NewLogger(unilogger.Options{
+		Format:   unilogger.FormatLogfmt,
+		Output:   buf,
+		TimeFunc: stubTimeFn,
+	})
+	unilogger.SetDefault(root)
+
+	db := root.Named("db")
+	http := root.Named("http")
+	client := http.Named("client")
+
+	t.Setenv(unilogger.EnvLogLevel, "warn,db=debug,http.*=error")
+	assert.NoError(t, unilogger.ConfigureFromEnv())
+
+	root.Info("root info")
+	db.Debug("db debug")
+	http.Info("http info")
+	client.Warn("client warn")
+	client.Error("client error")
+
+	assert.Equal(t, "level=debug logger=db msg=\"db debug\" time=2006-01-02T15:04:05Z\n"+
+		"level=error logger=http.client msg=\"client error\" time=2006-01-02T15:04:05Z\n", buf.String())
+
+	t.Setenv(unilogger.EnvLogLevel, "debug,db=verbose")
+	assert.Error(t, unilogger.ConfigureFromEnv())
+
+	// nothing is applied from a bad spec
+	buf.Reset()
+	root.Info("root info")
+	assert.Equal(t, "", buf.String())
+}
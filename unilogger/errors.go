@@ -0,0 +1,173 @@
+package unilogger
+
+import (
+	"encoding/json"
+	"log/slog"
+	"reflect"
+	"strconv"
+)
+
+// ErrorKey is the key of the Err attribute.
+const ErrorKey = "error"
+
+// how deep the wrapped errors are described, protects from cycles
+const maxErrorDepth = 16
+
+// Err returns an attribute which every handler renders as an object
+// with the message, the concrete type, the wrapped errors and the stack
+// trace of err, see ErrorInfo.
+func Err(err error) slog.Attr {
+	if err == nil {
+		return slog.Any(ErrorKey, nil)
+	}
+
+	return slog.Any(ErrorKey, errorValue{err: err})
+}
+
+// errorValue makes the stdlib handlers render the error like SlogHandler does.
+type errorValue struct {
+	err error
+}
+
+func (v errorValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(DescribeError(v.err))
+}
+
+func (v errorValue) MarshalText() ([]byte, error) {
+	return []byte(v.err.Error()), nil
+}
+
+// AsError reports whether v holds an error, passed either with Err or as is.
+// Errors which marshal themselves to JSON are left to their marshaler.
+func AsError(v slog.Value) (error, bool) {
+	if v.Kind() != slog.KindAny {
+		return nil, false
+	}
+
+	switch a := v.Any().(type) {
+	case errorValue:
+		return a.err, true
+	case json.Marshaler:
+		return nil, false
+	case error:
+		return a, true
+	default:
+		return nil, false
+	}
+}
+
+// ErrorInfo is the rendered form of an error.
+type ErrorInfo struct {
+	Msg  string `json:"msg"`
+	Type string `json:"type"`
+	// Causes are the errors from Unwrap() error or Unwrap() []error
+	Causes []ErrorInfo `json:"causes,omitempty"`
+	// Stack is the trace carried by the error, if any
+	Stack []Frame `json:"stack,omitempty"`
+}
+
+// DescribeError walks the errors.Unwrap and errors.Join chain of err.
+func DescribeError(err error) ErrorInfo {
+	return describeError(err, StackOptions{}, 0)
+}
+
+func describeError(err error, opts StackOptions, depth int) ErrorInfo {
+	info := ErrorInfo{
+		Msg:  err.Error(),
+		Type: reflect.TypeOf(err).String(),
+	}
+
+	if pcs := errorStack(err); len(pcs) > 0 {
+		info.Stack = opts.frames(pcs)
+	}
+
+	if depth >= maxErrorDepth {
+		return info
+	}
+
+	var causes []error
+
+	switch e := err.(type) {
+	case interface{ Unwrap() error }:
+		causes = append(causes, e.Unwrap())
+	case interface{ Unwrap() []error }:
+		causes = e.Unwrap()
+	}
+
+	for _, cause := range causes {
+		if cause != nil {
+			info.Causes = append(info.Causes, describeError(cause, opts, depth+1))
+		}
+	}
+
+	return info
+}
+
+// errorStack returns the callers carried by err. It knows errors with
+// Callers() []uintptr and the github.com/pkg/errors StackTrace() method,
+// which returns a slice of uintptr based frames.
+func errorStack(err error) []uintptr {
+	if e, ok := err.(interface{ Callers() []uintptr }); ok {
+		return e.Callers()
+	}
+
+	m := reflect.ValueOf(err).MethodByName("StackTrace")
+	if !m.IsValid() || m.Type().NumIn() != 0 || m.Type().NumOut() != 1 {
+		return nil
+	}
+
+	out := m.Type().Out(0)
+	if out.Kind() != reflect.Slice || out.Elem().Kind() != reflect.Uintptr {
+		return nil
+	}
+
+	trace := m.Call(nil)[0]
+	pcs := make([]uintptr, trace.Len())
+
+	for i := range pcs {
+		pcs[i] = uintptr(trace.Index(i).Uint())
+	}
+
+	return pcs
+}
+
+// appendError writes the error as an object in JSON and as
+// flattened key.msg, key.type, key.causes.N.msg pairs otherwise.
+func (s *handleState) appendError(key string, err error) {
+	info := describeError(err, s.h.stackOpts, 0)
+
+	if s.h.format == FormatJSON {
+		s.appendKey(key)
+		*s.buf = appendJSONMarshal(*s.buf, info)
+
+		return
+	}
+
+	s.appendErrorInfo(key, info)
+}
+
+func (s *handleState) appendErrorInfo(key string, info ErrorInfo) {
+	s.openGroup(key)
+
+	s.appendKey("msg")
+	s.appendString(info.Msg)
+	s.appendKey("type")
+	s.appendString(info.Type)
+
+	if len(info.Causes) > 0 {
+		s.openGroup("causes")
+
+		for i, cause := range info.Causes {
+			s.appendErrorInfo(strconv.Itoa(i), cause)
+		}
+
+		s.closeGroup("causes")
+	}
+
+	if len(info.Stack) > 0 {
+		s.appendKey("stack")
+		s.appendFrames(info.Stack)
+	}
+
+	s.closeGroup(key)
+}
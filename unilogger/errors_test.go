@@ -0,0 +1,165 @@
+package unilogger_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+
+	"slog-test/unilogger"
+)
+
+type stackError struct {
+	pcs []uintptr
+}
+
+func newStackError() *stackError {
+	pcs := make([]uintptr, 1)
+	runtime.Callers(2, pcs)
+
+	return &stackError{pcs: pcs}
+}
+
+func (e *stackError) Error() string      { return "stack error" }
+func (e *stackError) Callers() []uintptr { return e.pcs }
+
+func Test_Err(t *testing.T) {
+	t.Parallel()
+
+	type meta struct {
+		name    string
+		enabled bool
+	}
+
+	type args struct {
+		format unilogger.Format
+		attr   slog.Attr
+	}
+
+	type wants struct {
+		output string
+	}
+
+	tests := []struct {
+		meta  meta
+		args  args
+		wants wants
+	}{
+		{
+			meta: meta{
+				name:    "wrapped error",
+				enabled: true,
+			},
+			args: args{
+				attr: unilogger.Err(fmt.Errorf("outer: %w", errors.New("inner"))),
+			},
+			wants: wants{
+				output: `{"level":"error","msg":"stub msg","error":{"msg":"outer: inner","type":"*fmt.wrapError","causes":[{"msg":"inner","type":"*errors.errorString"}]},"time":"2006-01-02T15:04:05Z"}` + "\n",
+			},
+		},
+		{
+			meta: meta{
+				name:    "joined errors passed as is",
+				enabled: true,
+			},
+			args: args{
+				attr: slog.Any("err", errors.Join(errors.New("first"), errors.New("second"))),
+			},
+			wants: wants{
+				output: `{"level":"error","msg":"stub msg","err":{"msg":"first\nsecond","type":"*errors.joinError","causes":[{"msg":"first","type":"*errors.errorString"},{"msg":"second","type":"*errors.errorString"}]},"time":"2006-01-02T15:04:05Z"}` + "\n",
+			},
+		},
+		{
+			meta: meta{
+				name:    "nil error",
+				enabled: true,
+			},
+			args: args{
+				attr: unilogger.Err(nil),
+			},
+			wants: wants{
+				output: `{"level":"error","msg":"stub msg","error":null,"time":"2006-01-02T15:04:05Z"}` + "\n",
+			},
+		},
+		{
+			meta: meta{
+				name:    "logfmt flattens the error",
+				enabled: true,
+			},
+			args: args{
+				format: unilogger.FormatLogfmt,
+				attr:   unilogger.Err(fmt.Errorf("outer: %w", errors.New("inner"))),
+			},
+			wants: wants{
+				output: `level=error msg="stub msg" error.msg="outer: inner" error.type=*fmt.wrapError error.causes.0.msg=inner error.causes.0.type=*errors.errorString time=2006-01-02T15:04:05Z` + "\n",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		if !tt.meta.enabled {
+			continue
+		}
+
+		t.Run(tt.meta.name, func(t *testing.T) {
+			buf := bytes.NewBuffer([]byte{})
+
+			logger := unilogger.NewLogger(unilogger.Options{
+				Format:   tt.args.format,
+				Output:   buf,
+				TimeFunc: stubTimeFn,
+			})
+
+			logger.Error("stub msg", tt.args.attr)
+
+			assert.Equal(t, tt.wants.output, buf.String())
+		})
+	}
+}
+
+func Test_Err_Stack(t *testing.T) {
+	t.Parallel()
+
+	buf := bytes.NewBuffer([]byte{})
+
+	logger := unilogger.NewLogger(unilogger.Options{
+		Output: buf,
+	})
+
+	logger.Error("stub msg", unilogger.Err(fmt.Errorf("wrapped: %w", newStackError())))
+
+	var record struct {
+		Error unilogger.ErrorInfo `json:"error"`
+	}
+
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, 1, len(record.Error.Causes))
+	assert.Equal(t, 1, len(record.Error.Causes[0].Stack))
+	assert.True(t, strings.HasPrefix(record.Error.Causes[0].Stack[0].Function, "slog-test/unilogger_test.Test_Err_Stack"))
+}
+
+func Test_Err_SameInStdlibHandler(t *testing.T) {
+	t.Parallel()
+
+	err := unilogger.Err(fmt.Errorf("outer: %w", errors.Join(errors.New("first"), newStackError())))
+
+	var (
+		uniBuf = bytes.NewBuffer([]byte{})
+		stdBuf = bytes.NewBuffer([]byte{})
+	)
+
+	unilogger.NewLogger(unilogger.Options{Output: uniBuf}).Error("stub msg", err)
+	slog.New(slog.NewJSONHandler(stdBuf, nil)).Error("stub msg", err)
+
+	var uniRecord, stdRecord map[string]any
+
+	assert.NoError(t, json.Unmarshal(uniBuf.Bytes(), &uniRecord))
+	assert.NoError(t, json.Unmarshal(stdBuf.Bytes(), &stdRecord))
+	assert.Equal(t, uniRecord["error"], stdRecord["error"])
+}
@@ -3,8 +3,8 @@ package unilogger
 import (
 	"context"
 	"fmt"
+	"io"
 	"log/slog"
-	"os"
 	logContext "slog-test/unilogger/context"
 	"sync/atomic"
 )
@@ -25,10 +25,14 @@ func SetDefaultLevel(l Level) {
 	defaultLogger.Load().SetLevel(l)
 }
 
-func GetDefaultWriter() {
+// GetDefaultWriter returns the writer of the default logger.
+func GetDefaultWriter() io.Writer {
+	return Default().Output()
 }
 
-func SetDefaultWriter() {
+// SetDefaultWriter replaces the writer of the default logger, safe to call while logging.
+func SetDefaultWriter(w io.Writer) {
+	Default().SetOutput(w)
 }
 
 func Default() *Logger { return defaultLogger.Load() }
@@ -135,7 +139,7 @@ func Fatal(msg string, args ...any) {
 
 	Default().Log(ctx, LevelFatal.Level(), msg, args...)
 
-	os.Exit(1)
+	Default().exit()
 }
 
 func Fatalf(format string, args ...any) {
@@ -144,7 +148,7 @@ func Fatalf(format string, args ...any) {
 
 	Default().Log(ctx, LevelFatal.Level(), format, args...)
 
-	os.Exit(1)
+	Default().exit()
 }
 
 func FatalContext(ctx context.Context, msg string, args ...any) {
@@ -152,5 +156,5 @@ func FatalContext(ctx context.Context, msg string, args ...any) {
 	ctx = logContext.SetStackTraceContext(ctx, getStack())
 
 	Default().Log(ctx, LevelFatal.Level(), msg, args...)
-	os.Exit(1)
+	Default().exit()
 }
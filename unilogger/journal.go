@@ -0,0 +1,211 @@
+package unilogger
+
+import (
+	"encoding/binary"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// DefaultJournalSocket is the native protocol socket of systemd-journald.
+const DefaultJournalSocket = "/run/systemd/journal/socket"
+
+// JournalOptions are the fields added to every journal entry.
+type JournalOptions struct {
+	// Identifier is SYSLOG_IDENTIFIER, the base name of the executable if empty
+	Identifier string
+}
+
+// NewJournalHandler returns a handler which writes journald native protocol
+// entries: MESSAGE, PRIORITY by SyslogSeverity, SYSLOG_IDENTIFIER, LOGGER
+// with the logger name, CODE_FILE, CODE_LINE and CODE_FUNC when the source
+// is added, and a field per attribute. Attribute keys are joined with their
+// groups by '_', uppercased and the characters out of [A-Z0-9_] replaced
+// by '_'. Entries end with an empty line, see JournalWriter.
+func NewJournalHandler(out io.Writer, opts *slog.HandlerOptions, timeFn func(t time.Time) time.Time, jOpts JournalOptions) *SlogHandler {
+	return NewHandler(out, opts, timeFn).WithJournal(jOpts)
+}
+
+// WithJournal returns a handler which writes journald entries with the options.
+func (h *SlogHandler) WithJournal(opts JournalOptions) *SlogHandler {
+	if opts.Identifier == "" && len(os.Args) > 0 {
+		opts.Identifier = filepath.Base(os.Args[0])
+	}
+
+	h2 := h.clone()
+	h2.format = FormatJournal
+	h2.color = false
+	h2.journal = &opts
+
+	return h2
+}
+
+// journalField is a field written by the handler, not from an attribute.
+type journalField struct {
+	name  string
+	value string
+}
+
+// appendJournalRecord writes the record as a journal entry. The attributes
+// named like the fields of the handler are resolved by the key policy.
+func (s *handleState) appendJournalRecord() {
+	r := &s.record
+
+	var own [7]journalField
+
+	fields := append(own[:0],
+		journalField{"MESSAGE", r.Message},
+		journalField{"PRIORITY", strconv.Itoa(SyslogSeverity(Level(r.Level)))},
+	)
+
+	if id := s.h.journal.Identifier; id != "" {
+		fields = append(fields, journalField{"SYSLOG_IDENTIFIER", id})
+	}
+
+	if s.h.name != "" {
+		fields = append(fields, journalField{"LOGGER", s.h.name})
+	}
+
+	if s.addSource() && r.PC != 0 {
+		fields = s.appendJournalSource(fields, r.PC)
+	}
+
+	name := newBuffer()
+	value := newBuffer()
+	attrs := newBuffer()
+
+	defer name.Free()
+	defer value.Free()
+	defer attrs.Free()
+
+	s.flatAttrs(func(groups []string, a slog.Attr) {
+		*name = (*name)[:0]
+
+		for _, g := range groups {
+			*name = append(*name, g...)
+			*name = append(*name, '_')
+		}
+
+		*name = append(*name, a.Key...)
+		*name = journalFieldName(*name)
+
+		if len(*name) == 0 {
+			return
+		}
+
+		*value = appendPlainValue((*value)[:0], a.Value)
+
+		for {
+			i := slices.IndexFunc(fields, func(f journalField) bool {
+				return f.name == string(*name)
+			})
+			if i < 0 {
+				break
+			}
+
+			switch s.h.keyPolicy {
+			case KeyOverwrite:
+				fields[i].value = string(*value)
+
+				return
+			case KeyRename:
+				renamed := journalFieldName(append([]byte(s.h.renamePrefix), *name...))
+				if string(renamed) == string(*name) {
+					return
+				}
+
+				*name = renamed
+			case KeyError:
+				s.reportDuplicate(a.Key)
+
+				return
+			default:
+				return
+			}
+		}
+
+		*attrs = appendJournalField(*attrs, []byte(*name), []byte(*value))
+	})
+
+	for _, f := range fields {
+		*s.buf = appendJournalField(*s.buf, f.name, f.value)
+	}
+
+	*s.buf = append(*s.buf, *attrs...)
+	*s.buf = append(*s.buf, '\n')
+}
+
+// appendJournalSource adds the CODE_ fields, unless ReplaceAttr drops the source.
+func (s *handleState) appendJournalSource(fields []journalField, pc uintptr) []journalField {
+	if a := s.sourceAttr(pc); a.Equal(slog.Attr{}) {
+		return fields
+	}
+
+	fs := runtime.CallersFrames([]uintptr{pc})
+	f, _ := fs.Next()
+
+	return append(fields,
+		journalField{"CODE_FILE", f.File},
+		journalField{"CODE_LINE", strconv.Itoa(f.Line)},
+		journalField{"CODE_FUNC", f.Function},
+	)
+}
+
+// appendJournalField writes "NAME=value\n", or the binary form
+// "NAME\n<64-bit LE length>value\n" for the values with new lines.
+func appendJournalField[N, V string | []byte](buf []byte, name N, value V) []byte {
+	buf = append(buf, name...)
+
+	if !containsNewline(value) {
+		buf = append(buf, '=')
+		buf = append(buf, value...)
+
+		return append(buf, '\n')
+	}
+
+	buf = append(buf, '\n')
+	buf = binary.LittleEndian.AppendUint64(buf, uint64(len(value)))
+	buf = append(buf, value...)
+
+	return append(buf, '\n')
+}
+
+func containsNewline[S string | []byte](s S) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\n' {
+			return true
+		}
+	}
+
+	return false
+}
+
+// journalFieldName sanitizes the name in place: uppercased, [A-Z0-9_]
+// only, at most 64 characters and not starting with '_' or a digit,
+// which are reserved for the trusted fields.
+func journalFieldName(name []byte) []byte {
+	for i, c := range name {
+		switch {
+		case c >= 'a' && c <= 'z':
+			name[i] = c - 'a' + 'A'
+		case c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		default:
+			name[i] = '_'
+		}
+	}
+
+	for len(name) > 0 && (name[0] == '_' || name[0] >= '0' && name[0] <= '9') {
+		name = name[1:]
+	}
+
+	if len(name) > 64 {
+		name = name[:64]
+	}
+
+	return name
+}
@@ -0,0 +1,72 @@
+package unilogger
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	mfdCloexec      = 0x1
+	mfdAllowSealing = 0x2
+	fcntlAddSeals   = 1033
+	sealAll         = 0x1 | 0x2 | 0x4 | 0x8 // seal, shrink, grow, write
+	journalShmDir   = "/dev/shm"
+)
+
+// journalFile returns a sealed memfd with the entry, or an unlinked
+// file in /dev/shm where memfd is not available.
+func journalFile(entry []byte) (*os.File, error) {
+	f, err := memfd(entry)
+	if err == nil {
+		return f, nil
+	}
+
+	f, err = os.CreateTemp(journalShmDir, "journal-")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.Remove(f.Name()); err != nil {
+		f.Close()
+
+		return nil, err
+	}
+
+	if _, err := f.Write(entry); err != nil {
+		f.Close()
+
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func memfd(entry []byte) (*os.File, error) {
+	if sysMemfdCreate == 0 {
+		return nil, syscall.ENOSYS
+	}
+
+	name := []byte("journal-entry\x00")
+
+	fd, _, errno := syscall.Syscall(sysMemfdCreate, uintptr(unsafe.Pointer(&name[0])), mfdCloexec|mfdAllowSealing, 0)
+	if errno != 0 {
+		return nil, errno
+	}
+
+	f := os.NewFile(fd, "journal-entry")
+
+	if _, err := f.Write(entry); err != nil {
+		f.Close()
+
+		return nil, err
+	}
+
+	if _, _, errno := syscall.Syscall(syscall.SYS_FCNTL, fd, fcntlAddSeals, sealAll); errno != 0 {
+		f.Close()
+
+		return nil, errno
+	}
+
+	return f, nil
+}
@@ -0,0 +1,4 @@
+package unilogger
+
+// missing from syscall on amd64
+const sysMemfdCreate = 319
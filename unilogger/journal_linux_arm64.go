@@ -0,0 +1,5 @@
+package unilogger
+
+import "syscall"
+
+const sysMemfdCreate = syscall.SYS_MEMFD_CREATE
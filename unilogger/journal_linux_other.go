@@ -0,0 +1,6 @@
+//go:build linux && !amd64 && !arm64
+
+package unilogger
+
+// memfd is not used, the entries go to /dev/shm files
+const sysMemfdCreate = 0
@@ -0,0 +1,103 @@
+package unilogger_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+
+	"slog-test/unilogger"
+)
+
+// readJournalEntry receives a datagram of the stub journald, reading the
+// passed file descriptor if the datagram is empty.
+func readJournalEntry(t *testing.T, conn *net.UnixConn) string {
+	t.Helper()
+
+	buf := make([]byte, 64<<10)
+	oob := make([]byte, syscall.CmsgSpace(4))
+
+	n, oobn, _, _, err := conn.ReadMsgUnix(buf, oob)
+	assert.NoError(t, err)
+
+	if n > 0 {
+		return string(buf[:n])
+	}
+
+	msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(msgs))
+
+	fds, err := syscall.ParseUnixRights(&msgs[0])
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(fds))
+
+	if runtime.GOARCH == "amd64" || runtime.GOARCH == "arm64" {
+		link, err := os.Readlink(fmt.Sprintf("/proc/self/fd/%d", fds[0]))
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(link, "/memfd:journal-entry"), link)
+	}
+
+	f := os.NewFile(uintptr(fds[0]), "entry")
+	defer f.Close()
+
+	info, err := f.Stat()
+	assert.NoError(t, err)
+
+	data, err := io.ReadAll(io.NewSectionReader(f, 0, info.Size()))
+	assert.NoError(t, err)
+
+	return string(data)
+}
+
+func Test_JournalWriter(t *testing.T) {
+	t.Parallel()
+
+	addr := filepath.Join(shortTempDir(t), "journal.sock")
+
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: addr, Net: "unixgram"})
+	assert.NoError(t, err)
+
+	defer conn.Close()
+
+	w, err := unilogger.DialJournal(addr)
+	assert.NoError(t, err)
+
+	defer w.Close()
+
+	buf := bytes.NewBuffer([]byte{})
+
+	logger := unilogger.NewLogger(unilogger.Options{
+		Format:  unilogger.FormatJournal,
+		Journal: unilogger.JournalOptions{Identifier: "app"},
+		Output:  buf,
+	})
+
+	// two entries in a write, as the async output coalesces them
+	logger.Info("first")
+	logger.Warn("second\nline")
+
+	_, err = w.Write(buf.Bytes())
+	assert.NoError(t, err)
+
+	assert.Equal(t, "MESSAGE=first\nPRIORITY=6\nSYSLOG_IDENTIFIER=app\n", readJournalEntry(t, conn))
+	assert.Equal(t, "MESSAGE\n\x0b\x00\x00\x00\x00\x00\x00\x00second\nline\nPRIORITY=4\nSYSLOG_IDENTIFIER=app\n", readJournalEntry(t, conn))
+
+	// too large for a datagram, passed in a memfd
+	buf.Reset()
+	logger.Info("large", "payload", strings.Repeat("x", 4<<20))
+
+	_, err = w.Write(buf.Bytes())
+	assert.NoError(t, err)
+
+	entry := readJournalEntry(t, conn)
+	assert.Equal(t, strings.TrimSuffix(buf.String(), "\n"), entry)
+}
@@ -0,0 +1,28 @@
+//go:build !unix
+
+package unilogger
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+)
+
+var _ io.WriteCloser = (*JournalWriter)(nil)
+
+// JournalWriter is supported on unix only, as journald.
+type JournalWriter struct{}
+
+// DialJournal returns an error, journald is not available on the platform.
+func DialJournal(string) (*JournalWriter, error) {
+	return nil, fmt.Errorf("journal: %w on %s", errors.ErrUnsupported, runtime.GOOS)
+}
+
+func (w *JournalWriter) Write([]byte) (int, error) {
+	return 0, errors.ErrUnsupported
+}
+
+func (w *JournalWriter) Close() error {
+	return errors.ErrUnsupported
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package unilogger
+
+import (
+	"errors"
+	"os"
+)
+
+// journalFile is supported on linux only, as journald.
+func journalFile([]byte) (*os.File, error) {
+	return nil, errors.New("entry is too large")
+}
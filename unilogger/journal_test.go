@@ -0,0 +1,179 @@
+package unilogger_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+
+	"slog-test/unilogger"
+)
+
+func Test_JournalHandler(t *testing.T) {
+	t.Parallel()
+
+	type meta struct {
+		name    string
+		enabled bool
+	}
+
+	type fields struct {
+		logfn func(logger *unilogger.Logger)
+	}
+
+	type args struct {
+		policy unilogger.KeyPolicy
+	}
+
+	type wants struct {
+		output string
+	}
+
+	forged := func(logger *unilogger.Logger) {
+		logger.Named("db").Info("stub msg", "priority", 0, "message", "x", slog.Group("syslog", "identifier", "y"))
+	}
+
+	binaryMessage := func(msg string) string {
+		return "MESSAGE\n" + string(binary.LittleEndian.AppendUint64(nil, uint64(len(msg)))) + msg + "\n"
+	}
+
+	tests := []struct {
+		meta   meta
+		fields fields
+		args   args
+		wants  wants
+	}{
+		{
+			meta: meta{
+				name:    "message and priority",
+				enabled: true,
+			},
+			fields: fields{
+				logfn: func(logger *unilogger.Logger) {
+					logger.Info("stub msg")
+					logger.Trace("trace")
+				},
+			},
+			wants: wants{
+				output: "MESSAGE=stub msg\nPRIORITY=6\nSYSLOG_IDENTIFIER=app\n\n" +
+					"MESSAGE=trace\nPRIORITY=7\nSYSLOG_IDENTIFIER=app\n\n",
+			},
+		},
+		{
+			meta: meta{
+				name:    "fields from attrs",
+				enabled: true,
+			},
+			fields: fields{
+				logfn: func(logger *unilogger.Logger) {
+					logger.Named("db").With("user id", 1).WithGroup("req").
+						Warn("two\nlines", "path", "/x", "_trusted", "no", slog.Group("", "9lives", true))
+				},
+			},
+			wants: wants{
+				output: binaryMessage("two\nlines") +
+					"PRIORITY=4\nSYSLOG_IDENTIFIER=app\nLOGGER=db\nUSER_ID=1\nREQ_PATH=/x\nREQ__TRUSTED=no\nREQ_9LIVES=true\n\n",
+			},
+		},
+		{
+			meta: meta{
+				name:    "long and reserved names",
+				enabled: true,
+			},
+			fields: fields{
+				logfn: func(logger *unilogger.Logger) {
+					logger.Error("stub msg", "__cursor", "x", strings.Repeat("a", 70), 1)
+				},
+			},
+			wants: wants{
+				output: "MESSAGE=stub msg\nPRIORITY=3\nSYSLOG_IDENTIFIER=app\nCURSOR=x\n" + strings.Repeat("A", 64) + "=1\n\n",
+			},
+		},
+		{
+			meta: meta{
+				name:    "fields of the handler are renamed by default",
+				enabled: true,
+			},
+			fields: fields{logfn: forged},
+			wants: wants{
+				output: "MESSAGE=stub msg\nPRIORITY=6\nSYSLOG_IDENTIFIER=app\nLOGGER=db\n" +
+					"FIELDS_PRIORITY=0\nFIELDS_MESSAGE=x\nFIELDS_SYSLOG_IDENTIFIER=y\n\n",
+			},
+		},
+		{
+			meta: meta{
+				name:    "keep first drops the attrs",
+				enabled: true,
+			},
+			fields: fields{logfn: forged},
+			args:   args{policy: unilogger.KeyKeepFirst},
+			wants: wants{
+				output: "MESSAGE=stub msg\nPRIORITY=6\nSYSLOG_IDENTIFIER=app\nLOGGER=db\n\n",
+			},
+		},
+		{
+			meta: meta{
+				name:    "error drops the attrs",
+				enabled: true,
+			},
+			fields: fields{logfn: forged},
+			args:   args{policy: unilogger.KeyError},
+			wants: wants{
+				output: "MESSAGE=stub msg\nPRIORITY=6\nSYSLOG_IDENTIFIER=app\nLOGGER=db\n\n",
+			},
+		},
+		{
+			meta: meta{
+				name:    "overwrite replaces the fields",
+				enabled: true,
+			},
+			fields: fields{logfn: forged},
+			args:   args{policy: unilogger.KeyOverwrite},
+			wants: wants{
+				output: "MESSAGE=x\nPRIORITY=0\nSYSLOG_IDENTIFIER=y\nLOGGER=db\n\n",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		if !tt.meta.enabled {
+			continue
+		}
+
+		t.Run(tt.meta.name, func(t *testing.T) {
+			buf := bytes.NewBuffer([]byte{})
+
+			logger := unilogger.NewLogger(unilogger.Options{
+				Format:    unilogger.FormatJournal,
+				Journal:   unilogger.JournalOptions{Identifier: "app"},
+				Level:     slog.Level(unilogger.LevelTrace),
+				KeyPolicy: tt.args.policy,
+				Output:    buf,
+			})
+
+			tt.fields.logfn(logger)
+
+			assert.Equal(t, tt.wants.output, buf.String())
+		})
+	}
+}
+
+func Test_JournalHandler_Source(t *testing.T) {
+	t.Parallel()
+
+	buf := bytes.NewBuffer([]byte{})
+
+	logger := unilogger.NewLogger(unilogger.Options{
+		Format:  unilogger.FormatJournal,
+		Journal: unilogger.JournalOptions{Identifier: "app"},
+		Source:  &unilogger.SourcePolicy{Mode: unilogger.SourceAlways},
+		Output:  buf,
+	})
+
+	logger.Info("stub msg")
+
+	assert.Contains(t, buf.String(), "journal_test.go\nCODE_LINE=176\nCODE_FUNC=slog-test/unilogger_test.Test_JournalHandler_Source\n")
+}
@@ -0,0 +1,144 @@
+//go:build unix
+
+package unilogger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"syscall"
+)
+
+var _ io.WriteCloser = (*JournalWriter)(nil)
+
+// JournalWriter sends the entries written by the journal handler to
+// journald, a datagram each. Entries too large for a datagram are passed
+// in a sealed memfd, as sd_journal_send does.
+type JournalWriter struct {
+	mu   sync.Mutex
+	conn *net.UnixConn
+}
+
+// DialJournal connects to the journald socket at addr, DefaultJournalSocket if empty.
+func DialJournal(addr string) (*JournalWriter, error) {
+	if addr == "" {
+		addr = DefaultJournalSocket
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: addr, Net: "unixgram"})
+	if err != nil {
+		return nil, fmt.Errorf("journal: %w", err)
+	}
+
+	return &JournalWriter{conn: conn}, nil
+}
+
+// Write sends every entry of p, the entries are separated by empty lines.
+func (w *JournalWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for n := 0; n < len(p); {
+		size, err := journalEntrySize(p[n:])
+		if err != nil {
+			return n, err
+		}
+
+		if entry := p[n : n+size]; len(entry) > 1 {
+			// without the empty line
+			if err := w.send(entry[:len(entry)-1]); err != nil {
+				return n, err
+			}
+		}
+
+		n += size
+	}
+
+	return len(p), nil
+}
+
+func (w *JournalWriter) send(entry []byte) error {
+	_, err := w.conn.Write(entry)
+	if err == nil {
+		return nil
+	}
+
+	if !errors.Is(err, syscall.EMSGSIZE) && !errors.Is(err, syscall.ENOBUFS) {
+		return fmt.Errorf("journal: %w", err)
+	}
+
+	f, err := journalFile(entry)
+	if err != nil {
+		return fmt.Errorf("journal: %w", err)
+	}
+	defer f.Close()
+
+	if err := w.sendFD(int(f.Fd())); err != nil {
+		return fmt.Errorf("journal: %w", err)
+	}
+
+	return nil
+}
+
+// sendFD passes the file descriptor in an empty datagram. The connected
+// net.UnixConn refuses WriteMsgUnix, so it goes through sendmsg.
+func (w *JournalWriter) sendFD(fd int) error {
+	rc, err := w.conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	var sendErr error
+
+	err = rc.Write(func(s uintptr) bool {
+		sendErr = syscall.Sendmsg(int(s), nil, syscall.UnixRights(fd), nil, 0)
+
+		return !errors.Is(sendErr, syscall.EAGAIN)
+	})
+
+	return errors.Join(err, sendErr)
+}
+
+// journalEntrySize returns the size of the first entry in p with its
+// empty line, or all of p if the empty line is missing.
+func journalEntrySize(p []byte) (int, error) {
+	for n := 0; n < len(p); {
+		line, _, found := bytes.Cut(p[n:], []byte{'\n'})
+		if !found {
+			return len(p), nil
+		}
+
+		n += len(line) + 1
+
+		switch {
+		case len(line) == 0:
+			return n, nil
+		case bytes.IndexByte(line, '=') < 0:
+			// binary field: length, value and a new line
+			if len(p)-n < 8 {
+				return 0, errors.New("journal: truncated field")
+			}
+
+			size := binary.LittleEndian.Uint64(p[n:])
+			if size > uint64(len(p)-n-8) {
+				return 0, errors.New("journal: truncated field")
+			}
+
+			n += 8 + int(size) + 1
+		}
+	}
+
+	return len(p), nil
+}
+
+// Close closes the socket.
+func (w *JournalWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.conn.Close()
+}
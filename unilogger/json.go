@@ -0,0 +1,155 @@
+package unilogger
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"math"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+const hex = "0123456789abcdef"
+
+func appendJSONString(buf []byte, s string) []byte {
+	buf = append(buf, '"')
+	buf = appendEscapedJSONString(buf, s)
+
+	return append(buf, '"')
+}
+
+// appendEscapedJSONString escapes s the same way encoding/json does,
+// except that HTML characters are left as is.
+func appendEscapedJSONString(buf []byte, s string) []byte {
+	start := 0
+
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			if b >= ' ' && b != '"' && b != '\\' {
+				i++
+
+				continue
+			}
+
+			buf = append(buf, s[start:i]...)
+
+			switch b {
+			case '\\', '"':
+				buf = append(buf, '\\', b)
+			case '\n':
+				buf = append(buf, '\\', 'n')
+			case '\r':
+				buf = append(buf, '\\', 'r')
+			case '\t':
+				buf = append(buf, '\\', 't')
+			default:
+				buf = append(buf, '\\', 'u', '0', '0', hex[b>>4], hex[b&0xF])
+			}
+
+			i++
+			start = i
+
+			continue
+		}
+
+		c, size := utf8.DecodeRuneInString(s[i:])
+		if c == utf8.RuneError && size == 1 {
+			buf = append(buf, s[start:i]...)
+			buf = append(buf, `\ufffd`...)
+			i += size
+			start = i
+
+			continue
+		}
+
+		// U+2028 and U+2029 are valid JSON but break JavaScript parsers.
+		if c == '\u2028' || c == '\u2029' {
+			buf = append(buf, s[start:i]...)
+			buf = append(buf, '\\', 'u', '2', '0', '2', hex[c&0xF])
+			i += size
+			start = i
+
+			continue
+		}
+
+		i += size
+	}
+
+	return append(buf, s[start:]...)
+}
+
+func appendJSONValue(buf []byte, v slog.Value) []byte {
+	switch v.Kind() {
+	case slog.KindString:
+		return appendJSONString(buf, v.String())
+	case slog.KindInt64:
+		return strconv.AppendInt(buf, v.Int64(), 10)
+	case slog.KindUint64:
+		return strconv.AppendUint(buf, v.Uint64(), 10)
+	case slog.KindFloat64:
+		return appendJSONFloat(buf, v.Float64())
+	case slog.KindBool:
+		return strconv.AppendBool(buf, v.Bool())
+	case slog.KindDuration:
+		return strconv.AppendInt(buf, int64(v.Duration()), 10)
+	case slog.KindTime:
+		buf = append(buf, '"')
+		buf = v.Time().AppendFormat(buf, time.RFC3339Nano)
+
+		return append(buf, '"')
+	default:
+		a := v.Any()
+
+		if f, ok := a.(FormattedValue); ok {
+			return f.appendJSON(buf)
+		}
+
+		_, jm := a.(json.Marshaler)
+		if err, ok := a.(error); ok && !jm {
+			return appendJSONString(buf, err.Error())
+		}
+
+		return appendJSONMarshal(buf, a)
+	}
+}
+
+// appendJSONFloat formats f the way encoding/json does.
+// NaN and infinities have no JSON representation and are written as strings.
+func appendJSONFloat(buf []byte, f float64) []byte {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return appendJSONString(buf, strconv.FormatFloat(f, 'g', -1, 64))
+	}
+
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'e'
+	}
+
+	buf = strconv.AppendFloat(buf, f, format, -1, 64)
+
+	if format == 'e' {
+		// clean up e-09 to e-9
+		n := len(buf)
+		if n >= 4 && buf[n-4] == 'e' && buf[n-3] == '-' && buf[n-2] == '0' {
+			buf[n-2] = buf[n-1]
+			buf = buf[:n-1]
+		}
+	}
+
+	return buf
+}
+
+func appendJSONMarshal(buf []byte, v any) []byte {
+	var bb bytes.Buffer
+
+	enc := json.NewEncoder(&bb)
+	enc.SetEscapeHTML(false)
+
+	if err := enc.Encode(v); err != nil {
+		return appendJSONString(buf, "!ERROR:"+err.Error())
+	}
+
+	// Encode appends a newline
+	return append(buf, bytes.TrimRight(bb.Bytes(), "\n")...)
+}
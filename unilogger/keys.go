@@ -0,0 +1,364 @@
+package unilogger
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+)
+
+// KeyPolicy decides what happens to an attribute whose key is already
+// taken in the same object, by a built-in field or by an earlier attribute,
+// including the ones from repeated With calls.
+type KeyPolicy int
+
+const (
+	// KeyRename writes the later attributes under the rename prefix,
+	// like "fields.msg". It is the default, built-in fields always win.
+	KeyRename KeyPolicy = iota
+	// KeyKeepFirst drops the later attributes, built-in fields always win.
+	KeyKeepFirst
+	// KeyOverwrite keeps the last value. An attribute named like
+	// a built-in field replaces its value.
+	KeyOverwrite
+	// KeyError keeps the first value and makes Handle return ErrDuplicateKey.
+	KeyError
+)
+
+// DefaultRenamePrefix is the prefix of the keys renamed by KeyRename.
+const DefaultRenamePrefix = "fields."
+
+var ErrDuplicateKey = errors.New("duplicate key")
+
+// loggerName is the value of the LoggerName attribute,
+// SlogHandler moves it to the head of the record.
+type loggerName string
+
+// LoggerName returns the attribute used by Logger.Named. Unlike a plain
+// "logger" attribute it sets the logger field of the record.
+func LoggerName(name string) slog.Attr {
+	return slog.Any("logger", loggerName(name))
+}
+
+// groupedAttrs are the attrs of one WithAttrs call.
+type groupedAttrs struct {
+	groups []string
+	attrs  []slog.Attr
+	// attrs after ReplaceAttr, see resolveAttr
+	resolved []slog.Attr
+}
+
+// WithKeyPolicy returns a handler which resolves key collisions by the policy.
+// Empty renamePrefix means DefaultRenamePrefix.
+func (h *SlogHandler) WithKeyPolicy(policy KeyPolicy, renamePrefix string) *SlogHandler {
+	if renamePrefix == "" {
+		renamePrefix = DefaultRenamePrefix
+	}
+
+	h2 := h.clone()
+	h2.keyPolicy = policy
+	h2.renamePrefix = renamePrefix
+
+	return h2
+}
+
+var keysPool = sync.Pool{
+	New: func() any {
+		keys := make([]string, 0, 32)
+
+		return &keys
+	},
+}
+
+func (s *handleState) trackKeys() {
+	s.keys = keysPool.Get().(*[]string)
+}
+
+func (s *handleState) freeKeys() {
+	if s.keys != nil {
+		*s.keys = (*s.keys)[:0]
+		keysPool.Put(s.keys)
+	}
+}
+
+// checkKey marks the state collided, if the key is already taken
+// in the current object. Does nothing unless the keys are tracked.
+func (s *handleState) checkKey(key string) {
+	if s.keys == nil {
+		return
+	}
+
+	if s.depth == 0 {
+		if slices.Contains(s.h.preKeys, key) || (s.h.nOpenGroups == 0 && s.isReserved(key)) {
+			s.collided = true
+
+			return
+		}
+	}
+
+	if slices.Contains((*s.keys)[s.objStart:], key) {
+		s.collided = true
+
+		return
+	}
+
+	*s.keys = append(*s.keys, key)
+}
+
+// pushKeys starts tracking a nested object and returns
+// the start of the parent object for popKeys.
+func (s *handleState) pushKeys() int {
+	start := s.objStart
+	s.depth++
+
+	if s.keys != nil {
+		s.objStart = len(*s.keys)
+	}
+
+	return start
+}
+
+func (s *handleState) popKeys(start int) {
+	s.depth--
+
+	if s.keys != nil {
+		*s.keys = (*s.keys)[:s.objStart]
+		s.objStart = start
+	}
+}
+
+// isReserved reports whether the key is taken by a built-in field.
+// Without a record every field of the layout is assumed to be written.
+func (s *handleState) isReserved(key string) bool {
+	// console format writes built-in fields without keys
+	if s.h.format == FormatConsole {
+		return false
+	}
+
+	for _, fields := range [][]LayoutField{s.h.layout.Head, s.h.layout.Foot} {
+		for _, f := range fields {
+			if f.key() != key {
+				continue
+			}
+
+			switch f.Field {
+			case FieldLogger:
+				if s.h.name != "" {
+					return true
+				}
+			case FieldSource:
+				if !s.hasRecord || s.addSource() {
+					return true
+				}
+			case FieldTrace:
+				if !s.hasRecord || len(s.trace) > 0 {
+					return true
+				}
+			default:
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// attrNode is an attribute of the record body with the key collisions resolved.
+type attrNode struct {
+	key   string
+	value slog.Value
+	// object nodes are groups
+	object   bool
+	children []*attrNode
+	// structural nodes are the groups from WithGroup
+	structural bool
+	// group is the name of a structural node, its key may be renamed
+	group string
+}
+
+func (n *attrNode) empty() bool {
+	if !n.object {
+		return false
+	}
+
+	for _, c := range n.children {
+		if !c.empty() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// resolveKeys builds the record body from the WithAttrs attrs and
+// the record attrs, applying the key policy.
+func (s *handleState) resolveKeys() {
+	s.body = &attrNode{object: true}
+	s.collided = false
+
+	for _, ga := range s.h.attrs {
+		n := s.structuralNode(ga.groups)
+
+		for _, a := range ga.resolved {
+			s.insertAttr(n, a)
+		}
+	}
+
+	n := s.structuralNode(s.h.groups)
+
+	for _, a := range *s.attrs {
+		s.insertAttr(n, a)
+	}
+}
+
+// structuralNode returns the node of the groups, creating the missing ones.
+// A structural group can not be dropped, so when its key is taken
+// it is renamed whatever the policy is.
+func (s *handleState) structuralNode(groups []string) *attrNode {
+	n := s.body
+
+	for _, g := range groups {
+		i := slices.IndexFunc(n.children, func(c *attrNode) bool {
+			return c.structural && c.group == g
+		})
+		if i >= 0 {
+			n = n.children[i]
+
+			continue
+		}
+
+		child := &attrNode{key: g, object: true, structural: true, group: g}
+
+		for s.keyTaken(n, child.key) {
+			s.reportDuplicate(child.key)
+			child.key = s.h.renamePrefix + child.key
+		}
+
+		n.children = append(n.children, child)
+		n = child
+	}
+
+	return n
+}
+
+// insertAttr adds the attribute, already resolved by resolveAttr.
+func (s *handleState) insertAttr(n *attrNode, a slog.Attr) {
+	// elide empty attrs
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+
+	if a.Value.Kind() != slog.KindGroup {
+		s.insertNode(n, &attrNode{key: a.Key, value: a.Value})
+
+		return
+	}
+
+	// inline groups with empty key
+	if a.Key == "" {
+		for _, ga := range a.Value.Group() {
+			s.insertAttr(n, ga)
+		}
+
+		return
+	}
+
+	child := &attrNode{key: a.Key, object: true}
+
+	for _, ga := range a.Value.Group() {
+		s.insertAttr(child, ga)
+	}
+
+	// elide empty groups
+	if len(child.children) > 0 {
+		s.insertNode(n, child)
+	}
+}
+
+func (s *handleState) insertNode(n *attrNode, child *attrNode) {
+	for s.keyTaken(n, child.key) {
+		switch s.h.keyPolicy {
+		case KeyOverwrite:
+			if n == s.body && s.isReserved(child.key) {
+				if s.overrides == nil {
+					s.overrides = make(map[string]*attrNode)
+				}
+
+				s.overrides[child.key] = child
+
+				return
+			}
+
+			// structural groups can not be dropped
+			if slices.ContainsFunc(n.children, func(c *attrNode) bool {
+				return c.structural && c.key == child.key
+			}) {
+				child.key = s.h.renamePrefix + child.key
+
+				continue
+			}
+
+			n.children = slices.DeleteFunc(n.children, func(c *attrNode) bool {
+				return !c.structural && c.key == child.key
+			})
+		case KeyRename:
+			child.key = s.h.renamePrefix + child.key
+		case KeyError:
+			s.reportDuplicate(child.key)
+
+			return
+		default:
+			return
+		}
+	}
+
+	n.children = append(n.children, child)
+}
+
+// keyTaken reports whether the key is used in n by an attribute,
+// or by a built-in field when n is the top level.
+func (s *handleState) keyTaken(n *attrNode, key string) bool {
+	if n == s.body && s.isReserved(key) {
+		return true
+	}
+
+	return slices.ContainsFunc(n.children, func(c *attrNode) bool {
+		return c.key == key
+	})
+}
+
+func (s *handleState) reportDuplicate(key string) {
+	if s.h.keyPolicy == KeyError && s.keyErr == nil {
+		s.keyErr = fmt.Errorf("%w: %q", ErrDuplicateKey, key)
+	}
+}
+
+func (s *handleState) appendNodes(nodes []*attrNode) {
+	for _, n := range nodes {
+		s.appendNode(n)
+	}
+}
+
+func (s *handleState) appendNode(n *attrNode) {
+	if !n.object {
+		if err, ok := AsError(n.value); ok {
+			s.appendError(n.key, err)
+
+			return
+		}
+
+		s.appendKey(n.key)
+		s.appendValue(n.value)
+
+		return
+	}
+
+	if n.empty() {
+		return
+	}
+
+	s.openGroup(n.key)
+	s.appendNodes(n.children)
+	s.closeGroup(n.key)
+}
@@ -0,0 +1,432 @@
+package unilogger_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+
+	"slog-test/unilogger"
+)
+
+func Test_KeyPolicy(t *testing.T) {
+	t.Parallel()
+
+	type meta struct {
+		name    string
+		enabled bool
+	}
+
+	type fields struct {
+		logfn func(logger *unilogger.Logger)
+	}
+
+	type args struct {
+		policy    unilogger.KeyPolicy
+		format    unilogger.Format
+		addSource bool
+	}
+
+	type wants struct {
+		output string
+	}
+
+	reservedMsg := func(logger *unilogger.Logger) {
+		logger.Info("x", "msg", "y")
+	}
+
+	withTwice := func(logger *unilogger.Logger) {
+		logger.With("a", 1).With("a", 2).Info("x")
+	}
+
+	recordAfterWith := func(logger *unilogger.Logger) {
+		logger.With("a", 1).Info("x", "a", 2)
+	}
+
+	reservedWith := func(logger *unilogger.Logger) {
+		logger.Named("stub").With("logger", "user", "time", "now").Info("x")
+	}
+
+	groupAfterWith := func(logger *unilogger.Logger) {
+		logger.With("g", 1).WithGroup("g").With("a", 1).Info("m", "a", 2)
+	}
+
+	userSource := func(logger *unilogger.Logger) {
+		logger.Info("x", "source", "billing-api", slog.Group("g", "source", "inner"))
+	}
+
+	tests := []struct {
+		meta   meta
+		fields fields
+		args   args
+		wants  wants
+	}{
+		{
+			meta: meta{
+				name:    "built-in fields win by default",
+				enabled: true,
+			},
+			fields: fields{
+				logfn: func(logger *unilogger.Logger) {
+					logger.Info("x", "level", "foo", "time", "t")
+				},
+			},
+			args: args{},
+			wants: wants{
+				output: `{"level":"info","msg":"x","fields.level":"foo","fields.time":"t","time":"2006-01-02T15:04:05Z"}`,
+			},
+		},
+		{
+			meta: meta{
+				name:    "source attr without the source field",
+				enabled: true,
+			},
+			fields: fields{logfn: userSource},
+			args:   args{},
+			wants: wants{
+				output: `{"level":"info","msg":"x","source":"billing-api","g":{"source":"inner"},"time":"2006-01-02T15:04:05Z"}`,
+			},
+		},
+		{
+			meta: meta{
+				name:    "rename source attr",
+				enabled: true,
+			},
+			fields: fields{logfn: userSource},
+			args:   args{policy: unilogger.KeyRename, addSource: true},
+			wants: wants{
+				output: `{"level":"info","msg":"x","source":"unilogger/keys_test.go:58","fields.source":"billing-api","g":{"source":"inner"},"time":"2006-01-02T15:04:05Z"}`,
+			},
+		},
+		{
+			meta: meta{
+				name:    "keep first drops source attr",
+				enabled: true,
+			},
+			fields: fields{logfn: userSource},
+			args:   args{policy: unilogger.KeyKeepFirst, addSource: true},
+			wants: wants{
+				output: `{"level":"info","msg":"x","source":"unilogger/keys_test.go:58","g":{"source":"inner"},"time":"2006-01-02T15:04:05Z"}`,
+			},
+		},
+		{
+			meta: meta{
+				name:    "overwrite replaces the source",
+				enabled: true,
+			},
+			fields: fields{logfn: userSource},
+			args:   args{policy: unilogger.KeyOverwrite, addSource: true},
+			wants: wants{
+				output: `{"level":"info","msg":"x","source":"billing-api","g":{"source":"inner"},"time":"2006-01-02T15:04:05Z"}`,
+			},
+		},
+		{
+			meta: meta{
+				name:    "error keeps the source",
+				enabled: true,
+			},
+			fields: fields{logfn: userSource},
+			args:   args{policy: unilogger.KeyError, addSource: true},
+			wants: wants{
+				output: `{"level":"info","msg":"x","source":"unilogger/keys_test.go:58","g":{"source":"inner"},"time":"2006-01-02T15:04:05Z"}`,
+			},
+		},
+		{
+			meta: meta{
+				name:    "overwrite replaces the built-in field",
+				enabled: true,
+			},
+			fields: fields{logfn: reservedMsg},
+			args:   args{policy: unilogger.KeyOverwrite},
+			wants: wants{
+				output: `{"level":"info","msg":"y","time":"2006-01-02T15:04:05Z"}`,
+			},
+		},
+		{
+			meta: meta{
+				name:    "overwrite keeps the last with attr",
+				enabled: true,
+			},
+			fields: fields{logfn: withTwice},
+			args:   args{policy: unilogger.KeyOverwrite},
+			wants: wants{
+				output: `{"level":"info","msg":"x","a":2,"time":"2006-01-02T15:04:05Z"}`,
+			},
+		},
+		{
+			meta: meta{
+				name:    "overwrite keeps the record attr",
+				enabled: true,
+			},
+			fields: fields{logfn: recordAfterWith},
+			args:   args{policy: unilogger.KeyOverwrite},
+			wants: wants{
+				output: `{"level":"info","msg":"x","a":2,"time":"2006-01-02T15:04:05Z"}`,
+			},
+		},
+		{
+			meta: meta{
+				name:    "overwrite replaces the named logger",
+				enabled: true,
+			},
+			fields: fields{logfn: reservedWith},
+			args:   args{policy: unilogger.KeyOverwrite},
+			wants: wants{
+				output: `{"level":"info","logger":"user","msg":"x","time":"now"}`,
+			},
+		},
+		{
+			meta: meta{
+				name:    "keep first drops the attr",
+				enabled: true,
+			},
+			fields: fields{logfn: reservedMsg},
+			args:   args{policy: unilogger.KeyKeepFirst},
+			wants: wants{
+				output: `{"level":"info","msg":"x","time":"2006-01-02T15:04:05Z"}`,
+			},
+		},
+		{
+			meta: meta{
+				name:    "keep first keeps the first with attr",
+				enabled: true,
+			},
+			fields: fields{logfn: withTwice},
+			args:   args{policy: unilogger.KeyKeepFirst},
+			wants: wants{
+				output: `{"level":"info","msg":"x","a":1,"time":"2006-01-02T15:04:05Z"}`,
+			},
+		},
+		{
+			meta: meta{
+				name:    "rename reserved key",
+				enabled: true,
+			},
+			fields: fields{logfn: reservedMsg},
+			args:   args{policy: unilogger.KeyRename},
+			wants: wants{
+				output: `{"level":"info","msg":"x","fields.msg":"y","time":"2006-01-02T15:04:05Z"}`,
+			},
+		},
+		{
+			meta: meta{
+				name:    "rename record attr",
+				enabled: true,
+			},
+			fields: fields{logfn: recordAfterWith},
+			args:   args{policy: unilogger.KeyRename},
+			wants: wants{
+				output: `{"level":"info","msg":"x","a":1,"fields.a":2,"time":"2006-01-02T15:04:05Z"}`,
+			},
+		},
+		{
+			meta: meta{
+				name:    "rename with attrs named like built-in fields",
+				enabled: true,
+			},
+			fields: fields{logfn: reservedWith},
+			args:   args{policy: unilogger.KeyRename},
+			wants: wants{
+				output: `{"level":"info","logger":"stub","msg":"x","fields.logger":"user","fields.time":"now","time":"2006-01-02T15:04:05Z"}`,
+			},
+		},
+		{
+			meta: meta{
+				name:    "rename in logfmt",
+				enabled: true,
+			},
+			fields: fields{logfn: reservedMsg},
+			args:   args{policy: unilogger.KeyRename, format: unilogger.FormatLogfmt},
+			wants: wants{
+				output: `level=info msg=x fields.msg=y time=2006-01-02T15:04:05Z`,
+			},
+		},
+		{
+			meta: meta{
+				name:    "keys in different groups do not collide",
+				enabled: true,
+			},
+			fields: fields{
+				logfn: func(logger *unilogger.Logger) {
+					logger.With("a", 1).WithGroup("g").With("a", 2).Info("x", "msg", "y")
+				},
+			},
+			args: args{policy: unilogger.KeyRename},
+			wants: wants{
+				output: `{"level":"info","msg":"x","a":1,"g":{"a":2,"msg":"y"},"time":"2006-01-02T15:04:05Z"}`,
+			},
+		},
+		{
+			meta: meta{
+				name:    "with attr named like a later group",
+				enabled: true,
+			},
+			fields: fields{logfn: groupAfterWith},
+			args:   args{policy: unilogger.KeyRename},
+			wants: wants{
+				output: `{"level":"info","msg":"m","g":1,"fields.g":{"a":1,"fields.a":2},"time":"2006-01-02T15:04:05Z"}`,
+			},
+		},
+		{
+			meta: meta{
+				name:    "keep first in a renamed group",
+				enabled: true,
+			},
+			fields: fields{logfn: groupAfterWith},
+			args:   args{policy: unilogger.KeyKeepFirst},
+			wants: wants{
+				output: `{"level":"info","msg":"m","g":1,"fields.g":{"a":1},"time":"2006-01-02T15:04:05Z"}`,
+			},
+		},
+		{
+			meta: meta{
+				name:    "overwrite in a renamed group",
+				enabled: true,
+			},
+			fields: fields{logfn: groupAfterWith},
+			args:   args{policy: unilogger.KeyOverwrite},
+			wants: wants{
+				output: `{"level":"info","msg":"m","g":1,"fields.g":{"a":2},"time":"2006-01-02T15:04:05Z"}`,
+			},
+		},
+		{
+			meta: meta{
+				name:    "duplicates inside a group value",
+				enabled: true,
+			},
+			fields: fields{
+				logfn: func(logger *unilogger.Logger) {
+					logger.Info("x", slog.Group("g", "a", 1, "a", 2))
+				},
+			},
+			args: args{policy: unilogger.KeyRename},
+			wants: wants{
+				output: `{"level":"info","msg":"x","g":{"a":1,"fields.a":2},"time":"2006-01-02T15:04:05Z"}`,
+			},
+		},
+		{
+			meta: meta{
+				name:    "console format has no reserved keys",
+				enabled: true,
+			},
+			fields: fields{logfn: reservedMsg},
+			args:   args{policy: unilogger.KeyRename, format: unilogger.FormatConsole},
+			wants: wants{
+				output: `2006-01-02T15:04:05Z INFO  x msg=y`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		if !tt.meta.enabled {
+			continue
+		}
+
+		t.Run(tt.meta.name, func(t *testing.T) {
+			buf := bytes.NewBuffer([]byte{})
+
+			logger := unilogger.NewLogger(unilogger.Options{
+				AddSource: tt.args.addSource,
+				Format:    tt.args.format,
+				KeyPolicy: tt.args.policy,
+				Output:    buf,
+				TimeFunc:  stubTimeFn,
+			})
+
+			tt.fields.logfn(logger)
+
+			assert.Equal(t, tt.wants.output+"\n", buf.String())
+		})
+	}
+}
+
+func Test_KeyPolicy_Error(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{})
+
+	h := unilogger.NewHandler(buf, nil, stubTimeFn).
+		WithKeyPolicy(unilogger.KeyError, "").
+		WithAttrs([]slog.Attr{slog.Int("a", 1), slog.Int("a", 2)})
+
+	r := slog.NewRecord(stubTime, slog.LevelInfo, "x", 0)
+	r.AddAttrs(slog.String("msg", "y"))
+
+	err := h.Handle(context.Background(), r)
+
+	assert.IsError(t, err, unilogger.ErrDuplicateKey)
+	assert.Equal(t, `{"level":"info","msg":"x","a":1,"time":"2006-01-02T15:04:05Z"}`+"\n", buf.String())
+}
+
+func Test_KeyPolicy_OnError(t *testing.T) {
+	t.Parallel()
+
+	buf := bytes.NewBuffer([]byte{})
+
+	var errs []error
+
+	logger := unilogger.NewLogger(unilogger.Options{
+		Format:    unilogger.FormatJSON,
+		Output:    buf,
+		TimeFunc:  stubTimeFn,
+		KeyPolicy: unilogger.KeyError,
+		OnError:   func(err error) { errs = append(errs, err) },
+	})
+
+	logger.With("a", 1).Info("x", "a", 2)
+	logger.Info("y", "b", 1)
+
+	assert.Equal(t, 1, len(errs))
+	assert.IsError(t, errs[0], unilogger.ErrDuplicateKey)
+	assert.Equal(t, `{"level":"info","msg":"x","a":1,"time":"2006-01-02T15:04:05Z"}`+"\n"+
+		`{"level":"info","msg":"y","b":1,"time":"2006-01-02T15:04:05Z"}`+"\n", buf.String())
+}
+
+// counterValuer returns the number of its LogValue calls.
+type counterValuer struct {
+	calls int
+}
+
+func (v *counterValuer) LogValue() slog.Value {
+	v.calls++
+
+	return slog.IntValue(v.calls)
+}
+
+func Test_KeyPolicy_ResolveOnce(t *testing.T) {
+	t.Parallel()
+
+	buf := bytes.NewBuffer([]byte{})
+	replaced := 0
+
+	h := unilogger.NewHandler(buf, &slog.HandlerOptions{
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			replaced++
+
+			return a
+		},
+	}, stubTimeFn).WithKeyPolicy(unilogger.KeyRename, "")
+
+	// the record collides and is written again with the key policy
+	r := slog.NewRecord(stubTime, slog.LevelInfo, "m", 0)
+	r.AddAttrs(slog.Any("x", &counterValuer{}), slog.String("msg", "y"))
+
+	assert.NoError(t, h.Handle(context.Background(), r))
+	assert.Equal(t, 2, replaced)
+	assert.Equal(t, `{"level":"info","msg":"m","x":1,"fields.msg":"y","time":"2006-01-02T15:04:05Z"}`+"\n", buf.String())
+
+	// the with attrs collide, they are resolved once in WithAttrs
+	buf.Reset()
+	replaced = 0
+
+	h2 := h.WithAttrs([]slog.Attr{slog.Any("x", &counterValuer{}), slog.Int("x", 2)})
+	assert.Equal(t, 2, replaced)
+
+	for range 2 {
+		assert.NoError(t, h2.Handle(context.Background(), slog.NewRecord(stubTime, slog.LevelInfo, "m", 0)))
+	}
+
+	assert.Equal(t, 2, replaced)
+	assert.Equal(t, strings.Repeat(`{"level":"info","msg":"m","x":1,"fields.x":2,"time":"2006-01-02T15:04:05Z"}`+"\n", 2), buf.String())
+}
@@ -0,0 +1,77 @@
+package unilogger
+
+// Field is a built-in field of the record.
+type Field int
+
+const (
+	FieldLevel Field = iota + 1
+	FieldLogger
+	FieldMessage
+	FieldSource
+	FieldTrace
+	FieldTime
+)
+
+func (f Field) defaultKey() string {
+	switch f {
+	case FieldLevel:
+		return "level"
+	case FieldLogger:
+		return "logger"
+	case FieldMessage:
+		return "msg"
+	case FieldSource:
+		return "source"
+	case FieldTrace:
+		return "trace"
+	case FieldTime:
+		return "time"
+	default:
+		return ""
+	}
+}
+
+// LayoutField puts the built-in field to the record under Key.
+// Empty Key means the default key of the field.
+type LayoutField struct {
+	Field Field
+	Key   string
+}
+
+func (f LayoutField) key() string {
+	if f.Key != "" {
+		return f.Key
+	}
+
+	return f.Field.defaultKey()
+}
+
+// Layout sets which built-in fields are written, their order and key names.
+// Head fields go before the record attributes and Foot fields after them,
+// fields missing from both are not written.
+type Layout struct {
+	Head []LayoutField
+	Foot []LayoutField
+}
+
+// DefaultLayout is
+//
+//	{"level":..,"logger":..,"msg":..,"source":.., <attrs>, "trace":..,"time":..}
+func DefaultLayout() Layout {
+	return Layout{
+		Head: []LayoutField{
+			{Field: FieldLevel},
+			{Field: FieldLogger},
+			{Field: FieldMessage},
+			{Field: FieldSource},
+		},
+		Foot: []LayoutField{
+			{Field: FieldTrace},
+			{Field: FieldTime},
+		},
+	}
+}
+
+func (l Layout) isZero() bool {
+	return l.Head == nil && l.Foot == nil
+}
@@ -1,47 +1,51 @@
 package unilogger
 
 import (
-	"fmt"
-	"log/slog"
-)
+	"maps"
+	"sync"
+	"sync/atomic"
 
-var _ slog.Leveler = (*Level)(nil)
+	"slog-test/levels"
+)
 
-type Level slog.Level
+type Level = levels.Level
 
 const (
-	LevelTrace Level = -8
-	LevelDebug Level = -4
-	LevelInfo  Level = 0
-	LevelWarn  Level = 4
-	LevelError Level = 8
-	LevelFatal Level = 12
+	LevelTrace = levels.LevelTrace
+	LevelDebug = levels.LevelDebug
+	LevelInfo  = levels.LevelInfo
+	LevelWarn  = levels.LevelWarn
+	LevelError = levels.LevelError
+	LevelPanic = levels.LevelPanic
+	LevelFatal = levels.LevelFatal
 )
 
-func (l Level) Level() slog.Level {
-	return slog.Level(l)
-}
+// colors of the registered levels in the console format,
+// replaced as a whole by RegisterLevel
+var (
+	levelColors   atomic.Pointer[map[Level]string]
+	levelColorsMu sync.Mutex
+)
 
-func (l Level) String() string {
-	str := func(base string, val Level) string {
-		if val == 0 {
-			return base
-		}
-		return fmt.Sprintf("%s%+d", base, val)
+// RegisterLevel adds a named level, like "notice" at 2 or "audit" at 10,
+// see levels.Register. Color is an ANSI escape sequence like "\x1b[35m"
+// for the console format, the empty one keeps the color of the level below.
+func RegisterLevel(name string, level Level, color string) error {
+	if err := levels.Register(name, level); err != nil {
+		return err
 	}
 
-	switch {
-	case l < LevelDebug:
-		return str("trace", l-LevelTrace)
-	case l < LevelInfo:
-		return str("debug", l-LevelDebug)
-	case l < LevelWarn:
-		return str("info", l-LevelInfo)
-	case l < LevelError:
-		return str("warn", l-LevelWarn)
-	case l < LevelFatal:
-		return str("error", l-LevelError)
-	default:
-		return str("fatal", l-LevelFatal)
+	if color != "" {
+		levelColorsMu.Lock()
+		defer levelColorsMu.Unlock()
+
+		colors := map[Level]string{level: color}
+		if old := levelColors.Load(); old != nil {
+			maps.Copy(colors, *old)
+		}
+
+		levelColors.Store(&colors)
 	}
+
+	return nil
 }
@@ -0,0 +1,35 @@
+package unilogger_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+
+	"slog-test/unilogger"
+)
+
+func Test_RegisterLevel(t *testing.T) {
+	const levelAudit = unilogger.LevelError + 2
+
+	assert.NoError(t, unilogger.RegisterLevel("audit", levelAudit, "\x1b[35m"))
+	assert.Error(t, unilogger.RegisterLevel("audit", levelAudit+1, ""))
+
+	level, err := unilogger.ParseLevel("audit")
+	assert.NoError(t, err)
+	assert.Equal(t, levelAudit, level)
+
+	var (
+		jsonBuf    = bytes.NewBuffer([]byte{})
+		consoleBuf = bytes.NewBuffer([]byte{})
+	)
+
+	unilogger.NewLogger(unilogger.Options{Output: jsonBuf, TimeFunc: stubTimeFn}).
+		Log(context.Background(), levelAudit.Level(), "stub msg")
+	unilogger.NewLogger(unilogger.Options{Output: consoleBuf, TimeFunc: stubTimeFn, Format: unilogger.FormatConsole}).
+		Log(context.Background(), levelAudit.Level(), "stub msg")
+
+	assert.Equal(t, `{"level":"audit","msg":"stub msg","time":"2006-01-02T15:04:05Z"}`+"\n", jsonBuf.String())
+	assert.Equal(t, `2006-01-02T15:04:05Z AUDIT stub msg`+"\n", consoleBuf.String())
+}
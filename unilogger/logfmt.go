@@ -0,0 +1,20 @@
+package unilogger
+
+import (
+	"io"
+	"log/slog"
+	"time"
+)
+
+// NewLogfmtHandler returns a handler which writes logfmt lines with
+// the same head/body/foot layout as the JSON handler:
+//
+//	level=info logger=name msg="stub msg" group.key=value time=2006-01-02T15:04:05Z
+//
+// Groups are flattened into the keys, values are quoted when needed.
+func NewLogfmtHandler(out io.Writer, opts *slog.HandlerOptions, timeFn func(t time.Time) time.Time) *SlogHandler {
+	h := NewHandler(out, opts, timeFn)
+	h.format = FormatLogfmt
+
+	return h
+}
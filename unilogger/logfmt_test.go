@@ -0,0 +1,129 @@
+package unilogger_test
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+
+	"slog-test/unilogger"
+)
+
+func Test_LogfmtHandler(t *testing.T) {
+	t.Parallel()
+
+	type meta struct {
+		name    string
+		enabled bool
+	}
+
+	type fields struct {
+		logfn func(logger *unilogger.Logger)
+	}
+
+	type args struct {
+		layout unilogger.Layout
+	}
+
+	type wants struct {
+		output string
+	}
+
+	tests := []struct {
+		meta   meta
+		fields fields
+		args   args
+		wants  wants
+	}{
+		{
+			meta: meta{
+				name:    "head and foot fields keep the json layout",
+				enabled: true,
+			},
+			fields: fields{
+				logfn: func(logger *unilogger.Logger) {
+					logger.Named("stub").With("a", 1).Error("stub msg")
+				},
+			},
+			wants: wants{
+				output: `level=error logger=stub msg="stub msg" a=1 time=2006-01-02T15:04:05Z` + "\n",
+			},
+		},
+		{
+			meta: meta{
+				name:    "groups are flattened",
+				enabled: true,
+			},
+			fields: fields{
+				logfn: func(logger *unilogger.Logger) {
+					logger.With("a", 1).WithGroup("group").With("b", 2).
+						Info("msg", "c", 3, slog.Group("inner", "d", 4), slog.Group("empty"))
+				},
+			},
+			wants: wants{
+				output: `level=info msg=msg a=1 group.b=2 group.c=3 group.inner.d=4 time=2006-01-02T15:04:05Z` + "\n",
+			},
+		},
+		{
+			meta: meta{
+				name:    "values are quoted when needed",
+				enabled: true,
+			},
+			fields: fields{
+				logfn: func(logger *unilogger.Logger) {
+					logger.Info("msg", "empty", "", "eq", "a=b", "quote", `say "hi"`, "key with space", "ok", "dur", "1s")
+				},
+			},
+			wants: wants{
+				output: `level=info msg=msg empty="" eq="a=b" quote="say \"hi\"" "key with space"=ok dur=1s time=2006-01-02T15:04:05Z` + "\n",
+			},
+		},
+		{
+			meta: meta{
+				name:    "custom layout",
+				enabled: true,
+			},
+			fields: fields{
+				logfn: func(logger *unilogger.Logger) {
+					logger.Info("msg", "a", 1)
+				},
+			},
+			args: args{
+				layout: unilogger.Layout{
+					Head: []unilogger.LayoutField{
+						{Field: unilogger.FieldTime, Key: "ts"},
+						{Field: unilogger.FieldLevel, Key: "severity"},
+					},
+					Foot: []unilogger.LayoutField{
+						{Field: unilogger.FieldMessage, Key: "message"},
+					},
+				},
+			},
+			wants: wants{
+				output: `ts=2006-01-02T15:04:05Z severity=info a=1 message=msg` + "\n",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		if !tt.meta.enabled {
+			continue
+		}
+
+		t.Run(tt.meta.name, func(t *testing.T) {
+			buf := bytes.NewBuffer([]byte{})
+
+			logger := unilogger.NewLogger(unilogger.Options{
+				Format:   unilogger.FormatLogfmt,
+				Layout:   tt.args.layout,
+				Output:   buf,
+				TimeFunc: stubTimeFn,
+			})
+
+			tt.fields.logfn(logger)
+
+			assert.Equal(t, tt.wants.output, buf.String())
+		})
+	}
+}
@@ -2,16 +2,15 @@ package unilogger
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"os"
 	"path/filepath"
-	"runtime/debug"
-	"strings"
+	"slices"
 	"time"
 
+	"slog-test/levels"
 	logContext "slog-test/unilogger/context"
 )
 
@@ -21,9 +20,13 @@ type handlerOptions = *slog.HandlerOptions
 type Logger struct {
 	*logger
 
-	addSource *bool
-	level     *slog.Level
-	name      string
+	source *sourceSwitch
+	level  *slog.LevelVar
+	name   string
+	// levels of the named loggers, shared with the children
+	levels *levelTree
+	// level rules, shared with the children
+	rules *ruleSet
 
 	slogHandler *SlogHandler
 }
@@ -34,6 +37,31 @@ type Options struct {
 	AddSource bool
 	Level     slog.Level
 	Output    io.Writer
+	// Source overrides AddSource, see Logger.SetSourcePolicy
+	Source *SourcePolicy
+	// Format of the records, FormatJSON by default
+	Format Format
+	// Syslog is the header of FormatSyslog
+	Syslog SyslogOptions
+	// Journal are the fields of FormatJournal
+	Journal JournalOptions
+	// Layout of the built-in fields, DefaultLayout if empty
+	Layout Layout
+	// Stack selects the frames of the traces
+	Stack StackOptions
+	// KeyPolicy resolves the attributes with duplicate or reserved keys,
+	// KeyRename by default
+	KeyPolicy KeyPolicy
+	// KeyRenamePrefix is used by KeyRename, DefaultRenamePrefix if empty
+	KeyRenamePrefix string
+	// OnError gets the errors of the records, like ErrDuplicateKey of KeyError
+	// or the write errors, which are dropped otherwise
+	OnError func(err error)
+	// Async writes the records in the background, see Logger.Close
+	Async *AsyncOptions
+	// Sinks replace Output, Format and Async with several destinations,
+	// the records pass Level and the named levels before the sink levels
+	Sinks []Sink
 
 	TimeFunc func(t time.Time) time.Time
 }
@@ -49,6 +77,10 @@ func NewLogger(opts Options) *Logger {
 		opts.Output = os.Stdout
 	}
 
+	if opts.Layout.isZero() {
+		opts.Layout = DefaultLayout()
+	}
+
 	if opts.TimeFunc == nil {
 		opts.TimeFunc = func(t time.Time) time.Time {
 			return t
@@ -56,58 +88,209 @@ func NewLogger(opts Options) *Logger {
 	}
 
 	l := &Logger{
-		addSource: &opts.AddSource,
-		level:     &opts.Level,
+		level: new(slog.LevelVar),
 	}
+	l.level.Set(opts.Level)
 
 	handlerOpts := &slog.HandlerOptions{
-		AddSource: true,
+		AddSource: opts.AddSource,
 		Level:     l.level,
-		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
-			switch a.Key {
-			// skip standart fields
-			case slog.LevelKey, slog.MessageKey, slog.TimeKey:
-				return slog.Attr{}
-			case slog.SourceKey:
-				if !*l.addSource {
-					return slog.Attr{}
-				}
-
-				s := a.Value.Any().(*slog.Source)
-
-				dir, file := filepath.Split(s.File)
-
-				a.Value = slog.StringValue(fmt.Sprintf("%s:%d",
-					filepath.Join(filepath.Base(dir), file),
-					s.Line,
-				))
-			default:
-				key := strings.SplitN(a.Key, ";", 1)
-				if key[0] == "raw" {
-					a.Key = strings.Join(key[1:], ";")
-					a.Value = slog.StringValue(fmt.Sprintf("%#v", a.Value.Any()))
-				}
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			// user attrs may be keyed "source" too
+			if a.Key != slog.SourceKey || groups != nil {
+				return a
 			}
 
+			s, ok := a.Value.Any().(*slog.Source)
+			if !ok {
+				return a
+			}
+
+			dir, file := filepath.Split(s.File)
+
+			a.Value = slog.StringValue(fmt.Sprintf("%s:%d",
+				filepath.Join(filepath.Base(dir), file),
+				s.Line,
+			))
+
 			return a
 		},
 	}
 
-	l.slogHandler = NewHandler(opts.Output, handlerOpts, opts.TimeFunc)
+	switch opts.Format {
+	case FormatConsole:
+		l.slogHandler = NewConsoleHandler(opts.Output, handlerOpts, opts.TimeFunc)
+	case FormatLogfmt:
+		l.slogHandler = NewLogfmtHandler(opts.Output, handlerOpts, opts.TimeFunc).WithLayout(opts.Layout)
+	case FormatSyslog:
+		l.slogHandler = NewSyslogHandler(opts.Output, handlerOpts, opts.TimeFunc, opts.Syslog)
+	case FormatJournal:
+		l.slogHandler = NewJournalHandler(opts.Output, handlerOpts, opts.TimeFunc, opts.Journal)
+	default:
+		l.slogHandler = NewHandler(opts.Output, handlerOpts, opts.TimeFunc).WithLayout(opts.Layout)
+	}
 
+	l.slogHandler = l.slogHandler.
+		WithStackOptions(opts.Stack).
+		WithKeyPolicy(opts.KeyPolicy, opts.KeyRenamePrefix).
+		WithErrorHandler(opts.OnError)
+
+	if opts.Source != nil {
+		l.slogHandler.SetSourcePolicy(*opts.Source)
+	}
+
+	switch {
+	case len(opts.Sinks) > 0:
+		l.slogHandler = l.slogHandler.WithSinks(opts.Sinks...)
+	case opts.Async != nil:
+		l.slogHandler = l.slogHandler.WithAsync(*opts.Async)
+	}
+
+	l.source = l.slogHandler.source
+	l.levels = l.slogHandler.levels
+	l.rules = l.slogHandler.rules
 	l.logger = slog.New(l.slogHandler.WithAttrs(nil))
 
 	return l
 }
 
+// SetLevel sets the root level, or the level of the name for a Named logger.
 func (l *Logger) SetLevel(level Level) {
-	*l.addSource = slog.Level(level) <= slog.LevelDebug
+	if l.name != "" {
+		l.SetNamedLevel(l.name, level)
+
+		return
+	}
+
+	l.level.Set(slog.Level(level))
+}
+
+// SetSourcePolicy changes which records of the logger, its children and
+// its parents are annotated with the source. It is independent of the level.
+func (l *Logger) SetSourcePolicy(p SourcePolicy) {
+	if l.source != nil {
+		l.source.set(p)
+	}
+}
+
+// SourcePolicy returns the current source policy.
+func (l *Logger) SourcePolicy() SourcePolicy {
+	if l.source == nil {
+		return SourcePolicy{}
+	}
+
+	return l.source.get()
+}
+
+// SetLevelRules replaces the level rules of the logger, its children and its
+// parents at runtime, see LevelRule. The first matching rule wins over
+// the root and the named levels, WithLevel in the context can lower it.
+func (l *Logger) SetLevelRules(rules ...LevelRule) {
+	if l.rules != nil {
+		l.rules.set(rules)
+	}
+}
+
+// LevelRules returns the current level rules.
+func (l *Logger) LevelRules() []LevelRule {
+	if l.rules == nil {
+		return nil
+	}
+
+	return slices.Clone(l.rules.get())
+}
+
+// Level returns the effective level of the logger.
+func (l *Logger) Level() Level {
+	switch {
+	case l.name != "" && l.levels != nil:
+		return Level(l.levels.leveler(l.name).Level())
+	case l.level != nil:
+		return Level(l.level.Level())
+	default:
+		return LevelInfo
+	}
+}
+
+// SetNamedLevel sets the level of the named logger, like "db" or "http.client",
+// and of its descendants without their own levels. Patterns "db.*" and "*" set
+// only the descendants. Existing loggers see the change.
+func (l *Logger) SetNamedLevel(name string, level Level) {
+	if l.levels != nil {
+		l.levels.set(name, level)
+	}
+}
+
+// ResetNamedLevel makes the named logger or the pattern inherit the level
+// of the ancestors again.
+func (l *Logger) ResetNamedLevel(name string) {
+	if l.levels != nil {
+		l.levels.unset(name)
+	}
+}
 
-	*l.level = slog.Level(level)
+// NamedLevels returns the levels set for the names by SetNamedLevel,
+// without the patterns. Level returns the effective level of a logger.
+func (l *Logger) NamedLevels() map[string]Level {
+	if l.levels == nil {
+		return map[string]Level{}
+	}
+
+	return l.levels.configuredLevels()
 }
 
+// SetOutput replaces the writer of the logger, its children and its parents,
+// safe to call while logging.
 func (l *Logger) SetOutput(w io.Writer) {
-	l.slogHandler.w = w
+	if l.slogHandler != nil {
+		l.slogHandler.SetOutput(w)
+	}
+}
+
+// Output returns the current writer, nil for NewNop.
+func (l *Logger) Output() io.Writer {
+	if l.slogHandler == nil {
+		return nil
+	}
+
+	return l.slogHandler.Output()
+}
+
+// Flush waits until the records queued by the async output are written.
+func (l *Logger) Flush() error {
+	if l.slogHandler == nil {
+		return nil
+	}
+
+	return l.slogHandler.Flush()
+}
+
+// Sync flushes the logger and syncs its writer.
+func (l *Logger) Sync() error {
+	if l.slogHandler == nil {
+		return nil
+	}
+
+	return l.slogHandler.Sync()
+}
+
+// Close flushes the async output and stops its background writer,
+// it is shared by the logger, its children and its parents.
+func (l *Logger) Close() error {
+	if l.slogHandler == nil {
+		return nil
+	}
+
+	return l.slogHandler.Close()
+}
+
+// DroppedRecords returns the number of records dropped by the async output.
+func (l *Logger) DroppedRecords() uint64 {
+	if l.slogHandler == nil {
+		return 0
+	}
+
+	return l.slogHandler.DroppedRecords()
 }
 
 func (l *Logger) Named(name string) *Logger {
@@ -117,28 +300,40 @@ func (l *Logger) Named(name string) *Logger {
 	}
 
 	return &Logger{
-		logger:    l.logger.With(slog.String("logger", currName)),
-		addSource: l.addSource,
-		level:     l.level,
-		name:      currName,
+		logger: l.logger.With(LoggerName(currName)),
+		source: l.source,
+		level:  l.level,
+		name:   currName,
+		levels: l.levels,
+		rules:  l.rules,
+
+		slogHandler: l.slogHandler,
 	}
 }
 
 func (l *Logger) With(args ...any) *Logger {
 	return &Logger{
-		logger:    l.logger.With(args...),
-		addSource: l.addSource,
-		level:     l.level,
-		name:      l.name,
+		logger: l.logger.With(args...),
+		source: l.source,
+		level:  l.level,
+		name:   l.name,
+		levels: l.levels,
+		rules:  l.rules,
+
+		slogHandler: l.slogHandler,
 	}
 }
 
 func (l *Logger) WithGroup(name string) *Logger {
 	return &Logger{
-		logger:    l.logger.WithGroup(name),
-		addSource: l.addSource,
-		level:     l.level,
-		name:      l.name,
+		logger: l.logger.WithGroup(name),
+		source: l.source,
+		level:  l.level,
+		name:   l.name,
+		levels: l.levels,
+		rules:  l.rules,
+
+		slogHandler: l.slogHandler,
 	}
 }
 
@@ -187,7 +382,7 @@ func (l *Logger) Fatal(msg string, args ...any) {
 
 	l.Log(ctx, LevelFatal.Level(), msg, args...)
 
-	os.Exit(1)
+	l.exit()
 }
 
 func (l *Logger) Fatalf(format string, args ...any) {
@@ -196,53 +391,28 @@ func (l *Logger) Fatalf(format string, args ...any) {
 
 	l.Log(ctx, LevelFatal.Level(), fmt.Sprintf(format, args...))
 
-	os.Exit(1)
+	l.exit()
 }
 
-func getStack() string {
-	rawstack := string(debug.Stack())
-	rawstack = strings.ReplaceAll(rawstack, "\t", "")
-	splitted := strings.Split(rawstack, "\n")
-
-	splitted = splitted[2:]
-
-	return strings.Join(splitted, "")
+// exit writes the queued records out before exiting.
+func (l *Logger) exit() {
+	l.Close()
+	os.Exit(1)
 }
 
+// ParseLevel parses names like "debug" or "error+2", see levels.ParseLevel.
 func ParseLevel(rawLogLevel string) (Level, error) {
-	switch strings.ToLower(rawLogLevel) {
-	case "trace":
-		return LevelTrace, nil
-	case "debug":
-		return LevelDebug, nil
-	case "info":
-		return LevelInfo, nil
-	case "warn":
-		return LevelWarn, nil
-	case "error":
-		return LevelError, nil
-	case "fatal":
-		return LevelFatal, nil
-	default:
-		return LevelInfo, errors.New("no level found")
-	}
+	return levels.ParseLevel(rawLogLevel)
 }
 
+// LogLevelFromStr is ParseLevel which falls back to LevelInfo.
+//
+// Deprecated: use ParseLevel, which reports unknown levels.
 func LogLevelFromStr(rawLogLevel string) Level {
-	switch strings.ToLower(rawLogLevel) {
-	case "trace":
-		return LevelTrace
-	case "debug":
-		return LevelDebug
-	case "info":
-		return LevelInfo
-	case "warn":
-		return LevelWarn
-	case "error":
-		return LevelError
-	case "fatal":
-		return LevelFatal
-	default:
+	level, err := ParseLevel(rawLogLevel)
+	if err != nil {
 		return LevelInfo
 	}
+
+	return level
 }
@@ -73,7 +73,7 @@ func Test_Logger(t *testing.T) {
 		},
 		{
 			meta: meta{
-				name:    "logger change to debug level should contains addsource and debug level",
+				name:    "logger change to debug level should not change add source",
 				enabled: true,
 			},
 			fields: fields{
@@ -86,14 +86,96 @@ func Test_Logger(t *testing.T) {
 			},
 			wants: wants{
 				shouldContains: []string{
-					`{"level":"debug","msg":"stub msg","source":"unilogger/logger_test.go:19","stub_arg":"arg","time":"2006-01-02T15:04:05Z"}`,
-					`{"level":"info","msg":"stub msg","source":"unilogger/logger_test.go:20","stub_arg":"arg","time":"2006-01-02T15:04:05Z"}`,
+					`{"level":"debug","msg":"stub msg","stub_arg":"arg","time":"2006-01-02T15:04:05Z"}`,
+					`{"level":"fatal","msg":"stub msg","stub_arg":"arg","time":"2006-01-02T15:04:05Z"}`,
+				},
+				shouldNotContains: []string{
+					`"level":"trace"`,
+					`"source"`,
+				},
+			},
+		},
+		{
+			meta: meta{
+				name:    "add source option is kept after level change",
+				enabled: true,
+			},
+			fields: fields{
+				logfn: func(logger *unilogger.Logger) {
+					logger.SetLevel(unilogger.LevelError)
+				},
+			},
+			args: args{
+				addSource: true,
+				level:     unilogger.LevelDebug,
+			},
+			wants: wants{
+				shouldContains: []string{
+					`{"level":"error","msg":"stub msg","source":"unilogger/logger_test.go:22","stub_arg":"arg","time":"2006-01-02T15:04:05Z"}`,
+				},
+				shouldNotContains: []string{
+					`"level":"warn"`,
+				},
+			},
+		},
+		{
+			meta: meta{
+				name:    "source policy at least warn",
+				enabled: true,
+			},
+			fields: fields{
+				logfn: func(logger *unilogger.Logger) {
+					logger.SetSourcePolicy(unilogger.SourcePolicy{Mode: unilogger.SourceAtLeast, Level: unilogger.LevelWarn})
+				},
+			},
+			args: args{
+				level: unilogger.LevelDebug,
+			},
+			wants: wants{
+				shouldContains: []string{
+					`{"level":"debug","msg":"stub msg","stub_arg":"arg","time":"2006-01-02T15:04:05Z"}`,
+					`{"level":"info","msg":"stub msg","stub_arg":"arg","time":"2006-01-02T15:04:05Z"}`,
 					`{"level":"warn","msg":"stub msg","source":"unilogger/logger_test.go:21","stub_arg":"arg","time":"2006-01-02T15:04:05Z"}`,
 					`{"level":"error","msg":"stub msg","source":"unilogger/logger_test.go:22","stub_arg":"arg","time":"2006-01-02T15:04:05Z"}`,
 					`{"level":"fatal","msg":"stub msg","source":"unilogger/logger_test.go:24","stub_arg":"arg","time":"2006-01-02T15:04:05Z"}`,
 				},
-				shouldNotContains: []string{
-					`"level":"trace"`,
+			},
+		},
+		{
+			meta: meta{
+				name:    "source policy set on a child is shared",
+				enabled: true,
+			},
+			fields: fields{
+				logfn: func(logger *unilogger.Logger) {
+					logger.Named("child").With("a", 1).
+						SetSourcePolicy(unilogger.SourcePolicy{Mode: unilogger.SourceBelow, Level: unilogger.LevelInfo})
+				},
+			},
+			args: args{
+				level: unilogger.LevelDebug,
+			},
+			wants: wants{
+				shouldContains: []string{
+					`{"level":"debug","msg":"stub msg","source":"unilogger/logger_test.go:19","stub_arg":"arg","time":"2006-01-02T15:04:05Z"}`,
+					`{"level":"info","msg":"stub msg","stub_arg":"arg","time":"2006-01-02T15:04:05Z"}`,
+				},
+			},
+		},
+		{
+			meta: meta{
+				name:    "user attrs keyed source are kept",
+				enabled: true,
+			},
+			fields: fields{
+				logfn: func(logger *unilogger.Logger) {
+					logger.Info("user source", "source", "billing-api", slog.Group("g", "source", 1))
+				},
+			},
+			args: args{},
+			wants: wants{
+				shouldContains: []string{
+					`{"level":"info","msg":"user source","source":"billing-api","g":{"source":1},"time":"2006-01-02T15:04:05Z"}`,
 				},
 			},
 		},
@@ -135,3 +217,97 @@ func Test_Logger(t *testing.T) {
 		})
 	}
 }
+
+func Test_Logger_AttrsOrder(t *testing.T) {
+	t.Parallel()
+
+	type meta struct {
+		name    string
+		enabled bool
+	}
+
+	type fields struct {
+		logfn func(logger *unilogger.Logger)
+	}
+
+	type wants struct {
+		line string
+	}
+
+	tests := []struct {
+		meta   meta
+		fields fields
+		wants  wants
+	}{
+		{
+			meta: meta{
+				name:    "call site attrs keep insertion order",
+				enabled: true,
+			},
+			fields: fields{
+				logfn: func(logger *unilogger.Logger) {
+					logger.Info("stub msg", slog.String("z", "1"), slog.String("a", "2"), slog.String("m", "3"))
+				},
+			},
+			wants: wants{
+				line: `{"level":"info","msg":"stub msg","z":"1","a":"2","m":"3","time":"2006-01-02T15:04:05Z"}`,
+			},
+		},
+		{
+			meta: meta{
+				name:    "with attrs go before call site attrs",
+				enabled: true,
+			},
+			fields: fields{
+				logfn: func(logger *unilogger.Logger) {
+					logger.With("z", 1).With("b", 2).Named("stub").Info("stub msg", "y", 3, "a", 4)
+				},
+			},
+			wants: wants{
+				line: `{"level":"info","logger":"stub","msg":"stub msg","z":1,"b":2,"y":3,"a":4,"time":"2006-01-02T15:04:05Z"}`,
+			},
+		},
+		{
+			meta: meta{
+				name:    "nested groups keep insertion order",
+				enabled: true,
+			},
+			fields: fields{
+				logfn: func(logger *unilogger.Logger) {
+					logger.With("z", 1).
+						WithGroup("group").With("y", 2, "b", 3).
+						Info("stub msg", "x", 4, slog.Group("inner", "w", 5, "c", 6), "a", 7)
+				},
+			},
+			wants: wants{
+				line: `{"level":"info","msg":"stub msg","z":1,"group":{"y":2,"b":3,"x":4,"inner":{"w":5,"c":6},"a":7},"time":"2006-01-02T15:04:05Z"}`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		if !tt.meta.enabled {
+			continue
+		}
+
+		t.Run(tt.meta.name, func(t *testing.T) {
+			buf := bytes.NewBuffer([]byte{})
+
+			logger := unilogger.NewLogger(unilogger.Options{
+				Output: buf,
+				TimeFunc: func(_ time.Time) time.Time {
+					t, err := time.Parse(time.DateTime, "2006-01-02 15:04:05")
+					if err != nil {
+						panic(err)
+					}
+
+					return t
+				},
+			})
+
+			tt.fields.logfn(logger)
+
+			assert.Equal(t, tt.wants.line+"\n", buf.String())
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package unilogger
+
+import (
+	"log/slog"
+	"math"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// inheritLevel marks a name without a configured level
+const inheritLevel = math.MinInt64
+
+// levelTree keeps the levels of the named loggers. A name without a level
+// of its own inherits it from the nearest configured ancestor, "db.pool"
+// from "db", and the names without configured ancestors follow the root level.
+// A pattern like "db.*" configures only the descendants of "db",
+// and "*" every named logger. Only the configured names have nodes,
+// the loggers look their levels up lazily, see namedLevel.
+type levelTree struct {
+	root slog.Leveler
+
+	mu    sync.Mutex
+	nodes map[string]*levelNode
+	// gen changes with every set and unset, it invalidates the cached levels
+	gen atomic.Uint64
+}
+
+// levelNode is a configured name.
+type levelNode struct {
+	// own is the configured level or inheritLevel
+	own int64
+	// sub is the level configured for the descendants by a pattern or inheritLevel
+	sub int64
+}
+
+func newLevelTree(root slog.Leveler) *levelTree {
+	return &levelTree{
+		root:  root,
+		nodes: make(map[string]*levelNode),
+	}
+}
+
+func (t *levelTree) rootLevel() slog.Level {
+	if t.root == nil {
+		return slog.LevelInfo
+	}
+
+	return t.root.Level()
+}
+
+// namedLevel is the slog.Leveler of a named logger.
+type namedLevel struct {
+	tree  *levelTree
+	name  string
+	cache atomic.Pointer[cachedLevel]
+}
+
+// cachedLevel is the inherited level of a name in a generation of the tree.
+type cachedLevel struct {
+	gen   uint64
+	level int64
+}
+
+// leveler returns the leveler of the named logger.
+func (t *levelTree) leveler(name string) *namedLevel {
+	return &namedLevel{tree: t, name: name}
+}
+
+func (n *namedLevel) Level() slog.Level {
+	gen := n.tree.gen.Load()
+
+	c := n.cache.Load()
+	if c == nil || c.gen != gen {
+		n.tree.mu.Lock()
+		// gen is read again under the lock, set and unset change it holding the lock
+		c = &cachedLevel{gen: n.tree.gen.Load(), level: n.tree.inheritedLocked(n.name)}
+		n.tree.mu.Unlock()
+
+		n.cache.Store(c)
+	}
+
+	if c.level != inheritLevel {
+		return slog.Level(c.level)
+	}
+
+	return n.tree.rootLevel()
+}
+
+func (t *levelTree) nodeLocked(name string) *levelNode {
+	n, ok := t.nodes[name]
+	if !ok {
+		n = &levelNode{own: inheritLevel, sub: inheritLevel}
+		t.nodes[name] = n
+	}
+
+	return n
+}
+
+// inheritedLocked returns the level configured for the name or its nearest ancestor.
+func (t *levelTree) inheritedLocked(name string) int64 {
+	if n, ok := t.nodes[name]; ok && n.own != inheritLevel {
+		return n.own
+	}
+
+	for name != "" {
+		name = parentName(name)
+
+		n, ok := t.nodes[name]
+		if !ok {
+			continue
+		}
+
+		if n.sub != inheritLevel {
+			return n.sub
+		}
+
+		if n.own != inheritLevel && name != "" {
+			return n.own
+		}
+	}
+
+	return inheritLevel
+}
+
+func parentName(name string) string {
+	i := strings.LastIndexByte(name, '.')
+	if i < 0 {
+		return ""
+	}
+
+	return name[:i]
+}
+
+// splitPattern returns the name of the "name.*" and "*" patterns.
+func splitPattern(pattern string) (string, bool) {
+	if pattern == "*" {
+		return "", true
+	}
+
+	name, ok := strings.CutSuffix(pattern, ".*")
+
+	return name, ok
+}
+
+// set configures the level of the name and its descendants without their own
+// levels, or only of the descendants for a pattern.
+func (t *levelTree) set(name string, level Level) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if base, ok := splitPattern(name); ok {
+		t.nodeLocked(base).sub = int64(level)
+	} else {
+		t.nodeLocked(name).own = int64(level)
+	}
+
+	t.gen.Add(1)
+}
+
+// unset makes the name or the pattern inherit its level again.
+func (t *levelTree) unset(name string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	base, pattern := splitPattern(name)
+
+	n, ok := t.nodes[base]
+	if !ok {
+		return
+	}
+
+	if pattern {
+		n.sub = inheritLevel
+	} else {
+		n.own = inheritLevel
+	}
+
+	if n.own == inheritLevel && n.sub == inheritLevel {
+		delete(t.nodes, base)
+	}
+
+	t.gen.Add(1)
+}
+
+// configuredLevels returns the level of every name configured without a pattern.
+func (t *levelTree) configuredLevels() map[string]Level {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	levels := make(map[string]Level, len(t.nodes))
+	for name, n := range t.nodes {
+		// the nodes of the patterns only, like "" of "*"
+		if name == "" || n.own == inheritLevel {
+			continue
+		}
+
+		levels[name] = Level(n.own)
+	}
+
+	return levels
+}
@@ -0,0 +1,201 @@
+package unilogger_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+
+	"slog-test/unilogger"
+)
+
+func Test_Logger_NamedLevels(t *testing.T) {
+	t.Parallel()
+
+	type meta struct {
+		name    string
+		enabled bool
+	}
+
+	type fields struct {
+		setup func(root *unilogger.Logger)
+	}
+
+	type wants struct {
+		// names of the loggers whose debug records are written
+		debug []string
+	}
+
+	tests := []struct {
+		meta   meta
+		fields fields
+		wants  wants
+	}{
+		{
+			meta: meta{
+				name:    "children follow the root level",
+				enabled: true,
+			},
+			fields: fields{
+				setup: func(root *unilogger.Logger) {
+					root.SetLevel(unilogger.LevelDebug)
+				},
+			},
+			wants: wants{
+				debug: []string{"root", "db", "db.pool", "http", "http.client"},
+			},
+		},
+		{
+			meta: meta{
+				name:    "override applies to the name and its descendants",
+				enabled: true,
+			},
+			fields: fields{
+				setup: func(root *unilogger.Logger) {
+					root.SetNamedLevel("db", unilogger.LevelDebug)
+				},
+			},
+			wants: wants{
+				debug: []string{"db", "db.pool"},
+			},
+		},
+		{
+			meta: meta{
+				name:    "nearest configured ancestor wins",
+				enabled: true,
+			},
+			fields: fields{
+				setup: func(root *unilogger.Logger) {
+					root.SetLevel(unilogger.LevelDebug)
+					root.SetNamedLevel("http", unilogger.LevelWarn)
+					root.SetNamedLevel("http.client", unilogger.LevelDebug)
+				},
+			},
+			wants: wants{
+				debug: []string{"root", "db", "db.pool", "http.client"},
+			},
+		},
+		{
+			meta: meta{
+				name:    "pattern applies to the descendants only",
+				enabled: true,
+			},
+			fields: fields{
+				setup: func(root *unilogger.Logger) {
+					root.SetNamedLevel("http.*", unilogger.LevelDebug)
+					root.SetNamedLevel("db.*", unilogger.LevelDebug)
+					root.SetNamedLevel("db", unilogger.LevelError)
+				},
+			},
+			wants: wants{
+				debug: []string{"db.pool", "http.client"},
+			},
+		},
+		{
+			meta: meta{
+				name:    "reset inherits again",
+				enabled: true,
+			},
+			fields: fields{
+				setup: func(root *unilogger.Logger) {
+					root.SetNamedLevel("db", unilogger.LevelDebug)
+					root.SetNamedLevel("db.pool", unilogger.LevelError)
+					root.ResetNamedLevel("db.pool")
+				},
+			},
+			wants: wants{
+				debug: []string{"db", "db.pool"},
+			},
+		},
+		{
+			meta: meta{
+				name:    "set level of a named logger",
+				enabled: true,
+			},
+			fields: fields{
+				setup: func(root *unilogger.Logger) {
+					root.Named("http").Named("client").SetLevel(unilogger.LevelDebug)
+				},
+			},
+			wants: wants{
+				debug: []string{"http.client"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		if !tt.meta.enabled {
+			continue
+		}
+
+		t.Run(tt.meta.name, func(t *testing.T) {
+			buf := bytes.NewBuffer([]byte{})
+
+			root := unilogger.NewLogger(unilogger.Options{
+				Format:   unilogger.FormatLogfmt,
+				Output:   buf,
+				TimeFunc: stubTimeFn,
+			})
+
+			db := root.Named("db")
+			http := root.Named("http").With("a", 1)
+
+			// loggers exist before the levels change
+			loggers := map[string]*unilogger.Logger{
+				"root":        root,
+				"db":          db,
+				"db.pool":     db.Named("pool"),
+				"http":        http,
+				"http.client": http.Named("client"),
+			}
+
+			tt.fields.setup(root)
+
+			var got []string
+
+			for _, name := range []string{"root", "db", "db.pool", "http", "http.client"} {
+				buf.Reset()
+				loggers[name].Debug("stub msg")
+
+				if strings.Contains(buf.String(), "stub msg") {
+					got = append(got, name)
+				}
+			}
+
+			assert.Equal(t, tt.wants.debug, got)
+		})
+	}
+}
+
+func Test_Logger_NamedLevels_Effective(t *testing.T) {
+	t.Parallel()
+
+	root := unilogger.NewLogger(unilogger.Options{Output: bytes.NewBuffer([]byte{})})
+	pool := root.Named("db").Named("pool")
+	root.SetNamedLevel("db", unilogger.LevelDebug)
+	root.SetNamedLevel("http", unilogger.LevelError)
+	// no "cache" logger, only the pattern
+	root.SetNamedLevel("cache.*", unilogger.LevelWarn)
+	redis := root.Named("cache.redis")
+
+	// the named loggers are not listed, only the configured names
+	for i := range 100 {
+		root.Named(fmt.Sprintf("job%d", i)).With("n", i)
+	}
+
+	assert.Equal(t, map[string]unilogger.Level{
+		"db":   unilogger.LevelDebug,
+		"http": unilogger.LevelError,
+	}, root.NamedLevels())
+	assert.Equal(t, unilogger.LevelDebug, pool.Level())
+	assert.Equal(t, unilogger.LevelWarn, redis.Level())
+
+	root.ResetNamedLevel("db")
+	root.ResetNamedLevel("cache.*")
+
+	assert.Equal(t, map[string]unilogger.Level{"http": unilogger.LevelError}, root.NamedLevels())
+	assert.Equal(t, unilogger.LevelInfo, pool.Level())
+	assert.Equal(t, unilogger.LevelInfo, redis.Level())
+}
@@ -0,0 +1,348 @@
+package unilogger
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrCircuitOpen is returned for the records dropped while the collector
+// is unreachable.
+var ErrCircuitOpen = errors.New("circuit open: collector is unreachable")
+
+// replay batch size
+const spillReplayBatch = 64 << 10
+
+// NetWriterOptions configure NetWriter.
+type NetWriterOptions struct {
+	// DialTimeout is 5 seconds and WriteTimeout is 1 second if zero
+	DialTimeout  time.Duration
+	WriteTimeout time.Duration
+	// MinBackoff and MaxBackoff bound the delays between the reconnects,
+	// DefaultMinBackoff and DefaultMaxBackoff if zero
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// SpillDir keeps the records while the collector is unreachable,
+	// they are dropped if empty
+	SpillDir string
+	// SpillMaxBytes bounds the spilled records, DefaultSpillMaxBytes if zero
+	SpillMaxBytes int64
+}
+
+var _ io.WriteCloser = (*NetWriter)(nil)
+
+// NetWriter streams the JSON lines of the handler to a log collector over
+// "tcp", "udp" or "unix", a datagram per line for "udp". It works as a
+// circuit breaker: after a failed write it stops using the network and
+// reconnects in the background with an exponential backoff, so a dead
+// collector never blocks the logging. Meanwhile the records go to the
+// spill queue in SpillDir, which is replayed in order before the new
+// records once connected. Records are delivered at least once: the ones
+// in flight when the connection breaks may be lost or repeated.
+type NetWriter struct {
+	network string
+	addr    string
+	opts    NetWriterOptions
+	stream  bool
+
+	mu sync.Mutex
+	// conn is nil while the circuit is open
+	conn   net.Conn
+	spill  *spillQueue
+	closed bool
+
+	dropped atomic.Uint64
+
+	reconnect chan struct{}
+	done      chan struct{}
+	wg        sync.WaitGroup
+}
+
+// NewNetWriter returns a writer to the collector at addr. It connects at
+// once, if that fails or the spill queue left by the previous run is not
+// empty the writer starts with the circuit open.
+func NewNetWriter(network, addr string, opts NetWriterOptions) (*NetWriter, error) {
+	if opts.DialTimeout <= 0 {
+		opts.DialTimeout = 5 * time.Second
+	}
+
+	if opts.WriteTimeout <= 0 {
+		opts.WriteTimeout = time.Second
+	}
+
+	w := &NetWriter{
+		network:   network,
+		addr:      addr,
+		opts:      opts,
+		reconnect: make(chan struct{}, 1),
+		done:      make(chan struct{}),
+	}
+
+	switch network {
+	case "tcp", "tcp4", "tcp6", "unix":
+		w.stream = true
+	case "udp", "udp4", "udp6":
+	default:
+		return nil, fmt.Errorf("net writer: unsupported network %q", network)
+	}
+
+	if opts.SpillDir != "" {
+		spill, err := openSpillQueue(opts.SpillDir, opts.SpillMaxBytes)
+		if err != nil {
+			return nil, err
+		}
+
+		w.spill = spill
+	}
+
+	// the spilled records are replayed in the background
+	if w.spill == nil || w.spill.empty() {
+		if conn, err := net.DialTimeout(network, addr, opts.DialTimeout); err == nil {
+			w.conn = conn
+		}
+	}
+
+	if w.conn == nil {
+		w.tryReconnect()
+	}
+
+	w.wg.Add(1)
+
+	go w.reconnectLoop()
+
+	return w, nil
+}
+
+// Write sends the records, or spills them while the circuit is open.
+func (w *NetWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, net.ErrClosed
+	}
+
+	if w.conn != nil {
+		if err := w.send(w.conn, p); err == nil {
+			return len(p), nil
+		}
+
+		w.conn.Close()
+		w.conn = nil
+		w.tryReconnect()
+	}
+
+	if w.spill == nil {
+		w.dropped.Add(1)
+
+		return 0, ErrCircuitOpen
+	}
+
+	if err := w.spill.append(p); err != nil {
+		w.dropped.Add(1)
+
+		return 0, fmt.Errorf("%w: %w", ErrCircuitOpen, err)
+	}
+
+	return len(p), nil
+}
+
+func (w *NetWriter) send(conn net.Conn, p []byte) error {
+	if err := conn.SetWriteDeadline(time.Now().Add(w.opts.WriteTimeout)); err != nil {
+		return err
+	}
+
+	if w.stream {
+		_, err := conn.Write(p)
+
+		return err
+	}
+
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n') + 1
+		if i == 0 {
+			i = len(p)
+		}
+
+		if _, err := conn.Write(p[:i]); err != nil {
+			return err
+		}
+
+		p = p[i:]
+	}
+
+	return nil
+}
+
+func (w *NetWriter) tryReconnect() {
+	select {
+	case w.reconnect <- struct{}{}:
+	default:
+	}
+}
+
+func (w *NetWriter) reconnectLoop() {
+	defer w.wg.Done()
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-w.reconnect:
+		}
+
+		b := newBackoff(w.opts.MinBackoff, w.opts.MaxBackoff)
+
+		for !w.connect() {
+			b.fail(time.Now())
+
+			select {
+			case <-w.done:
+				return
+			case <-time.After(b.cur):
+			}
+		}
+	}
+}
+
+// connect dials the collector, replays the spilled records and closes
+// the circuit. It reports false if the collector is still unreachable.
+func (w *NetWriter) connect() bool {
+	w.mu.Lock()
+	connected := w.conn != nil || w.closed
+	w.mu.Unlock()
+
+	if connected {
+		return true
+	}
+
+	conn, err := net.DialTimeout(w.network, w.addr, w.opts.DialTimeout)
+	if err != nil {
+		return false
+	}
+
+	for {
+		w.mu.Lock()
+
+		if w.closed {
+			w.mu.Unlock()
+			conn.Close()
+
+			return true
+		}
+
+		if w.spill == nil || w.spill.empty() {
+			w.conn = conn
+			w.mu.Unlock()
+
+			return true
+		}
+
+		seg, _ := w.spill.head()
+		w.mu.Unlock()
+
+		if err := w.replay(conn, seg); err != nil {
+			conn.Close()
+
+			return false
+		}
+	}
+}
+
+// replay sends the segment from its offset and removes it.
+func (w *NetWriter) replay(conn net.Conn, seg spillSegment) error {
+	f, err := os.Open(seg.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := f.Seek(seg.offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	r := bufio.NewReader(f)
+	batch := make([]byte, 0, spillReplayBatch)
+
+	for {
+		line, err := r.ReadBytes('\n')
+		batch = append(batch, line...)
+
+		if len(batch) > 0 && (len(batch) >= spillReplayBatch || err != nil) {
+			if err := w.send(conn, batch); err != nil {
+				return err
+			}
+
+			w.mu.Lock()
+			w.spill.advance(int64(len(batch)))
+			w.mu.Unlock()
+
+			batch = batch[:0]
+		}
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.spill.pop()
+}
+
+// Connected reports whether the circuit is closed.
+func (w *NetWriter) Connected() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.conn != nil
+}
+
+// DroppedRecords returns the number of the writes dropped while the circuit was open.
+func (w *NetWriter) DroppedRecords() uint64 {
+	return w.dropped.Load()
+}
+
+// Close closes the connection and stops reconnecting. The records not
+// replayed yet stay in SpillDir for the next run.
+func (w *NetWriter) Close() error {
+	w.mu.Lock()
+
+	if w.closed {
+		w.mu.Unlock()
+
+		return net.ErrClosed
+	}
+
+	w.closed = true
+	close(w.done)
+
+	var errs []error
+
+	if w.conn != nil {
+		errs = append(errs, w.conn.Close())
+		w.conn = nil
+	}
+
+	if w.spill != nil {
+		errs = append(errs, w.spill.close())
+	}
+
+	w.mu.Unlock()
+
+	w.wg.Wait()
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,337 @@
+package unilogger_test
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/assert/v2"
+
+	"slog-test/unilogger"
+)
+
+// collector receives the lines of a stub log collector.
+type collector struct {
+	addr  string
+	lines chan string
+	stop  func()
+}
+
+func listenCollector(t *testing.T, network, addr string) *collector {
+	t.Helper()
+
+	c := &collector{lines: make(chan string, 64)}
+
+	if network == "udp" {
+		conn, err := net.ListenPacket(network, addr)
+		assert.NoError(t, err)
+
+		c.addr = conn.LocalAddr().String()
+		c.stop = func() { conn.Close() }
+
+		go func() {
+			buf := make([]byte, 64<<10)
+
+			for {
+				n, _, err := conn.ReadFrom(buf)
+				if err != nil {
+					return
+				}
+
+				c.lines <- strings.TrimSuffix(string(buf[:n]), "\n")
+			}
+		}()
+
+		t.Cleanup(c.stop)
+
+		return c
+	}
+
+	ln, err := net.Listen(network, addr)
+	assert.NoError(t, err)
+
+	var (
+		mu    sync.Mutex
+		conns []net.Conn
+	)
+
+	c.addr = ln.Addr().String()
+	c.stop = func() {
+		ln.Close()
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		for _, conn := range conns {
+			conn.Close()
+		}
+	}
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			mu.Lock()
+			conns = append(conns, conn)
+			mu.Unlock()
+
+			go func() {
+				defer conn.Close()
+
+				s := bufio.NewScanner(conn)
+				for s.Scan() {
+					c.lines <- s.Text()
+				}
+			}()
+		}
+	}()
+
+	t.Cleanup(c.stop)
+
+	return c
+}
+
+func (c *collector) next(t *testing.T) string {
+	t.Helper()
+
+	select {
+	case line := <-c.lines:
+		return line
+	case <-time.After(5 * time.Second):
+		t.Fatal("no line collected")
+
+		return ""
+	}
+}
+
+// waitFor polls the condition for up to 5 seconds.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	for deadline := time.Now().Add(5 * time.Second); !cond(); {
+		assert.True(t, time.Now().Before(deadline), "condition not met")
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// breakCircuit writes probes to the stopped collector until the writer notices.
+func breakCircuit(t *testing.T, w *unilogger.NetWriter) {
+	t.Helper()
+
+	waitFor(t, func() bool {
+		io.WriteString(w, `{"msg":"probe"}`+"\n")
+
+		return !w.Connected()
+	})
+}
+
+func Test_NetWriter(t *testing.T) {
+	t.Parallel()
+
+	for _, network := range []string{"tcp", "udp", "unix"} {
+		t.Run(network, func(t *testing.T) {
+			t.Parallel()
+
+			addr := "127.0.0.1:0"
+			if network == "unix" {
+				addr = filepath.Join(shortTempDir(t), "log.sock")
+			}
+
+			c := listenCollector(t, network, addr)
+
+			w, err := unilogger.NewNetWriter(network, c.addr, unilogger.NetWriterOptions{})
+			assert.NoError(t, err)
+
+			defer w.Close()
+
+			assert.True(t, w.Connected())
+
+			logger := unilogger.NewLogger(unilogger.Options{
+				Format:   unilogger.FormatJSON,
+				Output:   w,
+				TimeFunc: stubTimeFn,
+			})
+
+			logger.Info("first", "n", 1)
+			logger.Named("db").Error("second")
+
+			assert.Equal(t, `{"level":"info","msg":"first","n":1,"time":"2006-01-02T15:04:05Z"}`, c.next(t))
+			assert.Contains(t, c.next(t), `"msg":"second"`)
+		})
+	}
+}
+
+func Test_NetWriter_Spill(t *testing.T) {
+	t.Parallel()
+
+	type meta struct {
+		name    string
+		enabled bool
+	}
+
+	type fields struct {
+		spill bool
+	}
+
+	type wants struct {
+		lines   []string
+		err     error
+		dropped bool
+	}
+
+	tests := []struct {
+		meta   meta
+		fields fields
+		wants  wants
+	}{
+		{
+			meta: meta{
+				name:    "replayed in order",
+				enabled: true,
+			},
+			fields: fields{
+				spill: true,
+			},
+			wants: wants{
+				lines: []string{"c1", "c2", "c3", "live"},
+			},
+		},
+		{
+			meta: meta{
+				name:    "dropped without spill dir",
+				enabled: true,
+			},
+			fields: fields{
+				spill: false,
+			},
+			wants: wants{
+				lines:   []string{"live"},
+				err:     unilogger.ErrCircuitOpen,
+				dropped: true,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		if !tt.meta.enabled {
+			continue
+		}
+
+		t.Run(tt.meta.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := listenCollector(t, "tcp", "127.0.0.1:0")
+
+			opts := unilogger.NetWriterOptions{MinBackoff: 10 * time.Millisecond, MaxBackoff: 50 * time.Millisecond}
+			if tt.fields.spill {
+				opts.SpillDir = t.TempDir()
+			}
+
+			w, err := unilogger.NewNetWriter("tcp", c.addr, opts)
+			assert.NoError(t, err)
+
+			defer w.Close()
+
+			c.stop()
+			breakCircuit(t, w)
+
+			for _, line := range []string{"c1", "c2", "c3"} {
+				_, err := fmt.Fprintln(w, line)
+				assert.IsError(t, err, tt.wants.err)
+			}
+
+			assert.Equal(t, tt.wants.dropped, w.DroppedRecords() > 0)
+
+			// the collector is back on the same address
+			c = listenCollector(t, "tcp", c.addr)
+
+			waitFor(t, w.Connected)
+
+			_, err = fmt.Fprintln(w, "live")
+			assert.NoError(t, err)
+
+			var lines []string
+			for len(lines) < len(tt.wants.lines) {
+				if line := c.next(t); !strings.Contains(line, "probe") {
+					lines = append(lines, line)
+				}
+			}
+
+			assert.Equal(t, tt.wants.lines, lines)
+		})
+	}
+}
+
+func Test_NetWriter_SpillFull(t *testing.T) {
+	t.Parallel()
+
+	// nothing listens on the address
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	addr := ln.Addr().String()
+	ln.Close()
+
+	w, err := unilogger.NewNetWriter("tcp", addr, unilogger.NetWriterOptions{SpillDir: t.TempDir(), SpillMaxBytes: 8})
+	assert.NoError(t, err)
+
+	defer w.Close()
+
+	_, err = io.WriteString(w, "1234\n")
+	assert.NoError(t, err)
+
+	_, err = io.WriteString(w, "5678\n")
+	assert.IsError(t, err, unilogger.ErrCircuitOpen)
+	assert.Equal(t, uint64(1), w.DroppedRecords())
+}
+
+func Test_NetWriter_SpillPersisted(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	// nothing listens on the address
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	addr := ln.Addr().String()
+	ln.Close()
+
+	w, err := unilogger.NewNetWriter("tcp", addr, unilogger.NetWriterOptions{SpillDir: dir})
+	assert.NoError(t, err)
+
+	_, err = io.WriteString(w, "old1\nold2\n")
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+
+	// the next run replays the records left by the previous one
+	c := listenCollector(t, "tcp", "127.0.0.1:0")
+
+	w, err = unilogger.NewNetWriter("tcp", c.addr, unilogger.NetWriterOptions{SpillDir: dir, MinBackoff: 10 * time.Millisecond})
+	assert.NoError(t, err)
+
+	defer w.Close()
+
+	waitFor(t, w.Connected)
+
+	_, err = io.WriteString(w, "new\n")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "old1", c.next(t))
+	assert.Equal(t, "old2", c.next(t))
+	assert.Equal(t, "new", c.next(t))
+
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(entries))
+}
@@ -0,0 +1,5 @@
+//go:build !race
+
+package unilogger_test
+
+const raceEnabled = false
@@ -0,0 +1,76 @@
+package unilogger
+
+import (
+	"io"
+	"log/slog"
+	"sync"
+)
+
+// output is the writer shared by a handler and its clones. Records are
+// written whole under the lock, so the writer can be replaced while logging.
+type output struct {
+	mu sync.Mutex
+	w  io.Writer
+	// async queues the records for the background writer, nil if synchronous
+	async *asyncQueue
+}
+
+func (o *output) write(p []byte, level slog.Level) error {
+	if o.async != nil && o.async.push(p, level) {
+		return nil
+	}
+
+	return o.writeSync(p)
+}
+
+func (o *output) writeSync(p []byte) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	_, err := o.w.Write(p)
+
+	return err
+}
+
+func (o *output) writer() io.Writer {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.w
+}
+
+// swap replaces the writer after the record being written, if any.
+func (o *output) swap(w io.Writer) io.Writer {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	prev := o.w
+	o.w = w
+
+	return prev
+}
+
+// SetOutput replaces the writer of the handler and of the handlers derived
+// from it, safe to call while logging. The records queued by the async
+// output are written to the previous writer, which is returned. With sinks
+// it replaces the writer of the first one.
+func (h *SlogHandler) SetOutput(w io.Writer) io.Writer {
+	if len(h.sinks) > 0 {
+		return h.sinks[0].SetOutput(w)
+	}
+
+	if h.out.async != nil {
+		h.out.async.wait()
+	}
+
+	return h.out.swap(w)
+}
+
+// Output returns the current writer.
+func (h *SlogHandler) Output() io.Writer {
+	if len(h.sinks) > 0 {
+		return h.sinks[0].Output()
+	}
+
+	return h.out.writer()
+}
@@ -0,0 +1,114 @@
+package unilogger_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+
+	"slog-test/unilogger"
+)
+
+func Test_Logger_SetOutput_Concurrent(t *testing.T) {
+	t.Parallel()
+
+	const (
+		goroutines = 8
+		records    = 200
+		swaps      = 100
+	)
+
+	bufs := []*bytes.Buffer{
+		bytes.NewBuffer([]byte{}),
+		bytes.NewBuffer([]byte{}),
+		bytes.NewBuffer([]byte{}),
+	}
+
+	root := unilogger.NewLogger(unilogger.Options{Output: bufs[0]})
+	loggers := []*unilogger.Logger{
+		root,
+		root.Named("db"),
+		root.With("a", 1),
+		root.WithGroup("g").Named("http"),
+	}
+
+	var wg sync.WaitGroup
+
+	for i := range goroutines {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			logger := loggers[i%len(loggers)]
+			for j := range records {
+				logger.Info("stub msg", "goroutine", i, "record", j)
+			}
+		}()
+	}
+
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		for i := range swaps {
+			loggers[i%len(loggers)].SetOutput(bufs[i%len(bufs)])
+		}
+	}()
+
+	// levels and policies change while logging too
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		for i := range swaps {
+			root.SetLevel(unilogger.LevelInfo)
+			root.SetNamedLevel("db", unilogger.LevelDebug)
+			root.SetSourcePolicy(unilogger.SourcePolicy{Mode: unilogger.SourceMode(i % 2)})
+			root.SetLevelRules(unilogger.LevelRule{Match: map[string]string{"a": "1"}, Level: unilogger.LevelInfo})
+		}
+	}()
+
+	wg.Wait()
+
+	lines := 0
+
+	for _, buf := range bufs {
+		for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+			if line == "" {
+				continue
+			}
+
+			var record map[string]any
+
+			assert.NoError(t, json.Unmarshal([]byte(line), &record), line)
+
+			lines++
+		}
+	}
+
+	assert.Equal(t, goroutines*records, lines)
+}
+
+func Test_DefaultWriter(t *testing.T) {
+	prev := unilogger.Default()
+	t.Cleanup(func() { unilogger.SetDefault(prev) })
+
+	unilogger.SetDefault(unilogger.NewLogger(unilogger.Options{Output: io.Discard, TimeFunc: stubTimeFn}))
+
+	buf := bytes.NewBuffer([]byte{})
+	unilogger.SetDefaultWriter(buf)
+
+	assert.Equal[io.Writer](t, buf, unilogger.GetDefaultWriter())
+
+	unilogger.Default().Named("child").Info("stub msg")
+
+	assert.Equal(t, fmt.Sprintf(`{"level":"info","logger":"child","msg":"stub msg","time":"%s"}`+"\n", "2006-01-02T15:04:05Z"), buf.String())
+}
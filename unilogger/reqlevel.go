@@ -0,0 +1,88 @@
+package unilogger
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	logContext "slog-test/unilogger/context"
+)
+
+// LevelHeader is the request header read by LevelMiddleware.
+const LevelHeader = "X-Log-Level"
+
+// WithLevel returns a context whose records are logged at or above the level,
+// unless the level of the logger is lower already: it raises the verbosity
+// of a request and never hides records. It works with the *Context methods
+// and the Log methods taking ctx.
+func WithLevel(ctx context.Context, level Level) context.Context {
+	return logContext.SetLevelContext(ctx, level.Level())
+}
+
+// LevelFromContext returns the level set by WithLevel.
+func LevelFromContext(ctx context.Context) (Level, bool) {
+	level, ok := logContext.GetLevelContext(ctx)
+
+	return Level(level), ok
+}
+
+// SignLevelHeader returns the LevelHeader value which makes LevelMiddleware
+// log the request at the level until expires.
+func SignLevelHeader(secret []byte, level Level, expires time.Time) string {
+	payload := level.String() + ":" + strconv.FormatInt(expires.Unix(), 10)
+
+	return payload + ":" + base64.RawURLEncoding.EncodeToString(levelHeaderMAC(secret, payload))
+}
+
+// LevelMiddleware sets the level from LevelHeader to the request context
+// with WithLevel, if the header is signed with the secret, see SignLevelHeader,
+// and not expired. Requests with a missing or bad header are passed as is.
+func LevelMiddleware(secret []byte, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if level, ok := verifyLevelHeader(secret, r.Header.Get(LevelHeader), time.Now()); ok {
+			r = r.WithContext(WithLevel(r.Context(), level))
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func verifyLevelHeader(secret []byte, value string, now time.Time) (Level, bool) {
+	i := strings.LastIndexByte(value, ':')
+	if i < 0 || len(secret) == 0 {
+		return LevelInfo, false
+	}
+
+	payload := value[:i]
+
+	mac, err := base64.RawURLEncoding.DecodeString(value[i+1:])
+	if err != nil || !hmac.Equal(mac, levelHeaderMAC(secret, payload)) {
+		return LevelInfo, false
+	}
+
+	rawLevel, rawExpires, _ := strings.Cut(payload, ":")
+
+	expires, err := strconv.ParseInt(rawExpires, 10, 64)
+	if err != nil || now.Unix() > expires {
+		return LevelInfo, false
+	}
+
+	level, err := ParseLevel(rawLevel)
+	if err != nil {
+		return LevelInfo, false
+	}
+
+	return level, true
+}
+
+func levelHeaderMAC(secret []byte, payload string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+
+	return mac.Sum(nil)
+}
@@ -0,0 +1,140 @@
+package unilogger_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/assert/v2"
+
+	"slog-test/unilogger"
+)
+
+func Test_WithLevel(t *testing.T) {
+	t.Parallel()
+
+	buf := bytes.NewBuffer([]byte{})
+
+	logger := unilogger.NewLogger(unilogger.Options{
+		Format:   unilogger.FormatLogfmt,
+		Output:   buf,
+		TimeFunc: stubTimeFn,
+	})
+	db := logger.Named("db")
+	db.SetLevel(unilogger.LevelError)
+
+	ctx := unilogger.WithLevel(context.Background(), unilogger.LevelTrace)
+
+	logger.Debug("no ctx")
+	logger.DebugContext(ctx, "debug ctx")
+	logger.Logf(ctx, unilogger.LevelTrace, "trace %s", "ctx")
+	db.InfoContext(ctx, "named ctx")
+	db.InfoContext(unilogger.WithLevel(ctx, unilogger.LevelWarn), "quiet ctx")
+
+	// the context can not raise the level of the logger
+	logger.SetLevel(unilogger.LevelDebug)
+	logger.DebugContext(unilogger.WithLevel(ctx, unilogger.LevelError), "debug error ctx")
+
+	assert.Equal(t, `level=debug msg="debug ctx" time=2006-01-02T15:04:05Z`+"\n"+
+		`level=trace msg="trace ctx" time=2006-01-02T15:04:05Z`+"\n"+
+		`level=info logger=db msg="named ctx" time=2006-01-02T15:04:05Z`+"\n"+
+		`level=debug msg="debug error ctx" time=2006-01-02T15:04:05Z`+"\n", buf.String())
+}
+
+func Test_LevelMiddleware(t *testing.T) {
+	t.Parallel()
+
+	secret := []byte("stub secret")
+
+	type meta struct {
+		name    string
+		enabled bool
+	}
+
+	type args struct {
+		header string
+	}
+
+	type wants struct {
+		level unilogger.Level
+		ok    bool
+	}
+
+	tests := []struct {
+		meta  meta
+		args  args
+		wants wants
+	}{
+		{
+			meta: meta{
+				name:    "signed header",
+				enabled: true,
+			},
+			args: args{
+				header: unilogger.SignLevelHeader(secret, unilogger.LevelDebug+1, time.Now().Add(time.Minute)),
+			},
+			wants: wants{level: unilogger.LevelDebug + 1, ok: true},
+		},
+		{
+			meta: meta{
+				name:    "expired header",
+				enabled: true,
+			},
+			args: args{
+				header: unilogger.SignLevelHeader(secret, unilogger.LevelDebug, time.Now().Add(-time.Minute)),
+			},
+		},
+		{
+			meta: meta{
+				name:    "other secret",
+				enabled: true,
+			},
+			args: args{
+				header: unilogger.SignLevelHeader([]byte("other"), unilogger.LevelDebug, time.Now().Add(time.Minute)),
+			},
+		},
+		{
+			meta: meta{
+				name:    "unsigned header",
+				enabled: true,
+			},
+			args: args{header: "debug"},
+		},
+		{
+			meta: meta{
+				name:    "no header",
+				enabled: true,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		if !tt.meta.enabled {
+			continue
+		}
+
+		t.Run(tt.meta.name, func(t *testing.T) {
+			var (
+				level unilogger.Level
+				ok    bool
+			)
+
+			h := unilogger.LevelMiddleware(secret, http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				level, ok = unilogger.LevelFromContext(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.args.header != "" {
+				req.Header.Set(unilogger.LevelHeader, tt.args.header)
+			}
+
+			h.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, tt.wants.ok, ok)
+			assert.Equal(t, tt.wants.level, level)
+		})
+	}
+}
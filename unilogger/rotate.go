@@ -0,0 +1,457 @@
+package unilogger
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// time format of the backup names, sortable and safe for file names
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+const compressSuffix = ".gz"
+
+// RotateOptions are the policies of RotatingFile. Zero values disable them.
+type RotateOptions struct {
+	// MaxSize rotates the file before a write would make it larger, in bytes
+	MaxSize int64
+	// Interval rotates the file at the multiples of the interval since
+	// the zero time, so 24h rotates at midnight UTC
+	Interval time.Duration
+	// MaxBackups is the number of rotated files to keep
+	MaxBackups int
+	// MaxAge removes the rotated files older than it
+	MaxAge time.Duration
+	// Compress gzips the rotated files in the background
+	Compress bool
+	// Perm of the created files, 0644 by default
+	Perm os.FileMode
+	// Now is the clock, time.Now by default
+	Now func() time.Time
+}
+
+var _ io.WriteCloser = (*RotatingFile)(nil)
+
+// RotatingFile is a file writer for Options.Output which moves the file aside
+// as "name-<time>.ext" by size and time, and removes and compresses the rotated
+// files in the background. Writes are serialized, so it can be shared by
+// any number of loggers.
+type RotatingFile struct {
+	path string
+	opts RotateOptions
+
+	mu sync.Mutex
+	// file is nil after a failed rotation, it is opened again on the next write
+	file   *os.File
+	closed bool
+	size   int64
+	// time of the next interval rotation
+	rotateAt time.Time
+
+	// kicks the background removing and compressing
+	mill   chan struct{}
+	millWG sync.WaitGroup
+
+	errMu   sync.Mutex
+	millErr error
+}
+
+// NewRotatingFile opens the file at path for appending, creating it and its directory.
+func NewRotatingFile(path string, opts RotateOptions) (*RotatingFile, error) {
+	if opts.Perm == 0 {
+		opts.Perm = 0o644
+	}
+
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+
+	f := &RotatingFile{
+		path: path,
+		opts: opts,
+		mill: make(chan struct{}, 1),
+	}
+
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+
+	f.millWG.Add(1)
+
+	go f.millLoop()
+
+	// backups may be left from the previous runs
+	f.kickMill()
+
+	return f, nil
+}
+
+func (f *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o755); err != nil {
+		return fmt.Errorf("rotating file: %w", err)
+	}
+
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, f.opts.Perm)
+	if err != nil {
+		return fmt.Errorf("rotating file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+
+		return fmt.Errorf("rotating file: %w", err)
+	}
+
+	f.file = file
+	f.size = info.Size()
+
+	if f.opts.Interval > 0 {
+		f.rotateAt = f.opts.Now().Truncate(f.opts.Interval).Add(f.opts.Interval)
+	}
+
+	return nil
+}
+
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.reopen(); err != nil {
+		return 0, err
+	}
+
+	if f.needsRotation(int64(len(p))) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+
+	return n, err
+}
+
+func (f *RotatingFile) needsRotation(n int64) bool {
+	if f.opts.Interval > 0 {
+		if now := f.opts.Now(); !now.Before(f.rotateAt) {
+			if f.size > 0 {
+				return true
+			}
+
+			// nothing to move aside from the passed interval
+			f.rotateAt = now.Truncate(f.opts.Interval).Add(f.opts.Interval)
+		}
+	}
+
+	return f.size > 0 && f.opts.MaxSize > 0 && f.size+n > f.opts.MaxSize
+}
+
+// Rotate moves the current file aside and opens a new one.
+func (f *RotatingFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.reopen(); err != nil {
+		return err
+	}
+
+	return f.rotate()
+}
+
+// reopen opens the file again if a rotation failed to.
+func (f *RotatingFile) reopen() error {
+	if f.closed {
+		return os.ErrClosed
+	}
+
+	if f.file != nil {
+		return nil
+	}
+
+	return f.open()
+}
+
+func (f *RotatingFile) rotate() error {
+	closeErr := f.file.Close()
+	f.file = nil
+
+	var renameErr error
+	if closeErr == nil {
+		renameErr = os.Rename(f.path, f.backupName(f.opts.Now()))
+	}
+
+	// the new file, or the old one again if it was not moved aside
+	openErr := f.open()
+
+	if err := errors.Join(closeErr, renameErr); err != nil {
+		return errors.Join(fmt.Errorf("rotating file: %w", err), openErr)
+	}
+
+	if openErr != nil {
+		return openErr
+	}
+
+	f.kickMill()
+
+	return nil
+}
+
+// backupName returns a free name like "app-2006-01-02T15-04-05.000.log".
+func (f *RotatingFile) backupName(t time.Time) string {
+	dir, prefix, ext := f.nameParts()
+	base := filepath.Join(dir, prefix+t.UTC().Format(backupTimeFormat))
+
+	name := base + ext
+	for i := 1; fileExists(name) || fileExists(name+compressSuffix); i++ {
+		name = fmt.Sprintf("%s.%d%s", base, i, ext)
+	}
+
+	return name
+}
+
+func (f *RotatingFile) nameParts() (dir, prefix, ext string) {
+	dir, name := filepath.Split(f.path)
+	ext = filepath.Ext(name)
+
+	return dir, strings.TrimSuffix(name, ext) + "-", ext
+}
+
+func fileExists(name string) bool {
+	_, err := os.Lstat(name)
+
+	return err == nil
+}
+
+// Sync commits the file to the disk.
+func (f *RotatingFile) Sync() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.reopen(); err != nil {
+		return err
+	}
+
+	return f.file.Sync()
+}
+
+// Close closes the file and waits for the background work to finish.
+// It returns the errors of the background work too.
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+
+	if f.closed {
+		f.mu.Unlock()
+
+		return os.ErrClosed
+	}
+
+	var err error
+	if f.file != nil {
+		err = f.file.Close()
+		f.file = nil
+	}
+
+	f.closed = true
+	close(f.mill)
+
+	f.mu.Unlock()
+
+	f.millWG.Wait()
+
+	f.errMu.Lock()
+	defer f.errMu.Unlock()
+
+	return errors.Join(err, f.millErr)
+}
+
+func (f *RotatingFile) kickMill() {
+	select {
+	case f.mill <- struct{}{}:
+	default:
+	}
+}
+
+func (f *RotatingFile) millLoop() {
+	defer f.millWG.Done()
+
+	for range f.mill {
+		if err := f.millOnce(); err != nil {
+			f.errMu.Lock()
+			f.millErr = errors.Join(f.millErr, err)
+			f.errMu.Unlock()
+		}
+	}
+}
+
+type backupFile struct {
+	path string
+	t    time.Time
+}
+
+// millOnce removes the backups over the limits and compresses the rest.
+func (f *RotatingFile) millOnce() error {
+	backups, err := f.backups()
+	if err != nil {
+		return err
+	}
+
+	var (
+		errs   []error
+		cutoff time.Time
+	)
+
+	if f.opts.MaxAge > 0 {
+		cutoff = f.opts.Now().Add(-f.opts.MaxAge)
+	}
+
+	for i, b := range backups {
+		if (f.opts.MaxBackups > 0 && i >= f.opts.MaxBackups) || b.t.Before(cutoff) {
+			if err := os.Remove(b.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				errs = append(errs, err)
+			}
+
+			continue
+		}
+
+		if f.opts.Compress && !strings.HasSuffix(b.path, compressSuffix) {
+			if err := compressFile(b.path); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// backups returns the rotated files, the newest first.
+func (f *RotatingFile) backups() ([]backupFile, error) {
+	dir, prefix, ext := f.nameParts()
+
+	if dir == "" {
+		dir = "."
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []backupFile
+
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		t, ok := parseBackupName(name, prefix, ext)
+		if !ok {
+			continue
+		}
+
+		backups = append(backups, backupFile{path: filepath.Join(dir, name), t: t})
+	}
+
+	slices.SortStableFunc(backups, func(a, b backupFile) int {
+		if c := b.t.Compare(a.t); c != 0 {
+			return c
+		}
+
+		return strings.Compare(b.path, a.path)
+	})
+
+	return backups, nil
+}
+
+// parseBackupName parses the names of backupName, optionally gzipped,
+// like "app-2006-01-02T15-04-05.000.1.log.gz", and reports false for the rest.
+func parseBackupName(name, prefix, ext string) (time.Time, bool) {
+	rest, ok := strings.CutPrefix(name, prefix)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	rest = strings.TrimSuffix(rest, compressSuffix)
+
+	rest, ok = strings.CutSuffix(rest, ext)
+	if !ok || len(rest) < len(backupTimeFormat) {
+		return time.Time{}, false
+	}
+
+	stamp, counter := rest[:len(backupTimeFormat)], rest[len(backupTimeFormat):]
+
+	// the counter of the same time backups
+	if counter != "" {
+		digits, ok := strings.CutPrefix(counter, ".")
+		if !ok || digits == "" || strings.Trim(digits, "0123456789") != "" {
+			return time.Time{}, false
+		}
+	}
+
+	t, err := time.Parse(backupTimeFormat, stamp)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return t, true
+}
+
+// compressFile replaces the file with its gzipped copy.
+func compressFile(path string) (err error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	tmp := path + compressSuffix + ".tmp"
+
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode())
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			os.Remove(tmp)
+		}
+	}()
+
+	zw := gzip.NewWriter(dst)
+
+	if _, err = io.Copy(zw, src); err != nil {
+		dst.Close()
+
+		return err
+	}
+
+	if err = zw.Close(); err != nil {
+		dst.Close()
+
+		return err
+	}
+
+	if err = dst.Close(); err != nil {
+		return err
+	}
+
+	if err = os.Rename(tmp, path+compressSuffix); err != nil {
+		return err
+	}
+
+	return os.Remove(path)
+}
@@ -0,0 +1,390 @@
+package unilogger_test
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/assert/v2"
+
+	"slog-test/unilogger"
+)
+
+// stubClock is a settable clock for the rotation policies.
+type stubClock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func (c *stubClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.t
+}
+
+func (c *stubClock) Add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.t = c.t.Add(d)
+}
+
+// readLogDir returns the names of the files in dir and their contents,
+// gunzipped, the oldest backup first and the current file last.
+func readLogDir(t *testing.T, dir string) ([]string, []string) {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+
+	var names []string
+
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+
+	slices.SortFunc(names, func(a, b string) int {
+		// the current file sorts after its backups
+		return strings.Compare(strings.Replace(a, ".log", "-~.log", 1), strings.Replace(b, ".log", "-~.log", 1))
+	})
+
+	contents := make([]string, 0, len(names))
+
+	for _, name := range names {
+		file, err := os.Open(filepath.Join(dir, name))
+		assert.NoError(t, err)
+
+		var r io.Reader = file
+
+		if strings.HasSuffix(name, ".gz") {
+			r, err = gzip.NewReader(file)
+			assert.NoError(t, err)
+		}
+
+		data, err := io.ReadAll(r)
+		assert.NoError(t, err)
+		assert.NoError(t, file.Close())
+
+		contents = append(contents, string(data))
+	}
+
+	return names, contents
+}
+
+func Test_RotatingFile(t *testing.T) {
+	t.Parallel()
+
+	type meta struct {
+		name    string
+		enabled bool
+	}
+
+	type args struct {
+		opts unilogger.RotateOptions
+		// writes are the written lines, a "+" line moves the clock by an hour
+		writes []string
+	}
+
+	type wants struct {
+		names    []string
+		contents []string
+	}
+
+	tests := []struct {
+		meta  meta
+		args  args
+		wants wants
+	}{
+		{
+			meta: meta{
+				name:    "no policies",
+				enabled: true,
+			},
+			args: args{
+				writes: []string{"a\n", "b\n", "+", "c\n"},
+			},
+			wants: wants{
+				names:    []string{"app.log"},
+				contents: []string{"a\nb\nc\n"},
+			},
+		},
+		{
+			meta: meta{
+				name:    "by size",
+				enabled: true,
+			},
+			args: args{
+				opts:   unilogger.RotateOptions{MaxSize: 4},
+				writes: []string{"a\n", "b\n", "c\n", "long line\n", "d\n"},
+			},
+			wants: wants{
+				names: []string{
+					"app-2006-01-02T15-04-05.000.log",
+					"app-2006-01-02T15-04-05.000.1.log",
+					"app-2006-01-02T15-04-05.000.2.log",
+					"app.log",
+				},
+				contents: []string{"a\nb\n", "c\n", "long line\n", "d\n"},
+			},
+		},
+		{
+			meta: meta{
+				name:    "by interval",
+				enabled: true,
+			},
+			args: args{
+				opts:   unilogger.RotateOptions{Interval: time.Hour},
+				writes: []string{"a\n", "b\n", "+", "c\n", "+", "+", "d\n"},
+			},
+			wants: wants{
+				names: []string{
+					"app-2006-01-02T16-04-05.000.log",
+					"app-2006-01-02T18-04-05.000.log",
+					"app.log",
+				},
+				contents: []string{"a\nb\n", "c\n", "d\n"},
+			},
+		},
+		{
+			meta: meta{
+				name:    "max backups",
+				enabled: true,
+			},
+			args: args{
+				opts:   unilogger.RotateOptions{Interval: time.Hour, MaxBackups: 2},
+				writes: []string{"a\n", "+", "b\n", "+", "c\n", "+", "d\n"},
+			},
+			wants: wants{
+				names: []string{
+					"app-2006-01-02T17-04-05.000.log",
+					"app-2006-01-02T18-04-05.000.log",
+					"app.log",
+				},
+				contents: []string{"b\n", "c\n", "d\n"},
+			},
+		},
+		{
+			meta: meta{
+				name:    "max age",
+				enabled: true,
+			},
+			args: args{
+				opts:   unilogger.RotateOptions{Interval: time.Hour, MaxAge: 90 * time.Minute},
+				writes: []string{"a\n", "+", "b\n", "+", "c\n", "+", "d\n"},
+			},
+			wants: wants{
+				names: []string{
+					"app-2006-01-02T17-04-05.000.log",
+					"app-2006-01-02T18-04-05.000.log",
+					"app.log",
+				},
+				contents: []string{"b\n", "c\n", "d\n"},
+			},
+		},
+		{
+			meta: meta{
+				name:    "compress",
+				enabled: true,
+			},
+			args: args{
+				opts:   unilogger.RotateOptions{Interval: time.Hour, MaxBackups: 1, Compress: true},
+				writes: []string{"a\n", "+", "b\n", "+", "c\n"},
+			},
+			wants: wants{
+				names: []string{
+					"app-2006-01-02T17-04-05.000.log.gz",
+					"app.log",
+				},
+				contents: []string{"b\n", "c\n"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		if !tt.meta.enabled {
+			continue
+		}
+
+		t.Run(tt.meta.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			clock := &stubClock{t: stubTime}
+
+			opts := tt.args.opts
+			opts.Now = clock.Now
+
+			file, err := unilogger.NewRotatingFile(filepath.Join(dir, "app.log"), opts)
+			assert.NoError(t, err)
+
+			for _, w := range tt.args.writes {
+				if w == "+" {
+					clock.Add(time.Hour)
+
+					continue
+				}
+
+				_, err := io.WriteString(file, w)
+				assert.NoError(t, err)
+			}
+
+			assert.NoError(t, file.Close())
+
+			names, contents := readLogDir(t, dir)
+			assert.Equal(t, tt.wants.names, names)
+			assert.Equal(t, tt.wants.contents, contents)
+		})
+	}
+}
+
+func Test_RotatingFile_NamedLoggers(t *testing.T) {
+	t.Parallel()
+
+	const (
+		goroutines = 8
+		records    = 100
+	)
+
+	dir := t.TempDir()
+
+	file, err := unilogger.NewRotatingFile(filepath.Join(dir, "app.log"), unilogger.RotateOptions{
+		MaxSize:  4 << 10,
+		Compress: true,
+	})
+	assert.NoError(t, err)
+
+	root := unilogger.NewLogger(unilogger.Options{
+		Format: unilogger.FormatLogfmt,
+		Output: file,
+	})
+
+	var wg sync.WaitGroup
+
+	for i := range goroutines {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			logger := root.Named(fmt.Sprintf("worker%d", i))
+			for j := range records {
+				logger.Info("stub msg", "record", j)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	assert.NoError(t, file.Close())
+
+	names, contents := readLogDir(t, dir)
+	assert.True(t, len(names) > 1)
+
+	lines := strings.Split(strings.Join(contents, ""), "\n")
+	assert.Equal(t, goroutines*records+1, len(lines))
+
+	for _, line := range lines[:len(lines)-1] {
+		assert.Contains(t, line, `msg="stub msg"`)
+	}
+}
+
+func Test_RotatingFile_RenameFailure(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+
+	file, err := unilogger.NewRotatingFile(path, unilogger.RotateOptions{})
+	assert.NoError(t, err)
+
+	_, err = io.WriteString(file, "lost\n")
+	assert.NoError(t, err)
+
+	// the rename fails with the file gone
+	assert.NoError(t, os.Remove(path))
+	assert.IsError(t, file.Rotate(), os.ErrNotExist)
+
+	_, err = io.WriteString(file, "after\n")
+	assert.NoError(t, err)
+	assert.NoError(t, file.Close())
+	assert.IsError(t, file.Close(), os.ErrClosed)
+
+	_, err = io.WriteString(file, "closed\n")
+	assert.IsError(t, err, os.ErrClosed)
+
+	names, contents := readLogDir(t, dir)
+	assert.Equal(t, []string{"app.log"}, names)
+	assert.Equal(t, []string{"after\n"}, contents)
+}
+
+func Test_RotatingFile_Backups(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	clock := &stubClock{t: stubTime}
+
+	// only the names made by the rotation are backups
+	decoys := []string{
+		"app-2006-01-02T10-04-05.000.log.gz.tmp",
+		"app-2006-01-02T10-04-05.000x.log",
+		"app-2006-01-02T10-04-05.000.x.log",
+		"app-2006-01-02T10-04-05.000.log.bak",
+		"app-notes.log",
+	}
+
+	for _, name := range decoys {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("decoy\n"), 0o600))
+	}
+
+	// an old backup with the counter of the same time backups
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "app-2006-01-02T10-04-05.000.1.log"), []byte("old\n"), 0o600))
+
+	file, err := unilogger.NewRotatingFile(filepath.Join(dir, "app.log"), unilogger.RotateOptions{
+		Interval:   time.Hour,
+		MaxBackups: 1,
+		Compress:   true,
+		Now:        clock.Now,
+	})
+	assert.NoError(t, err)
+
+	for _, w := range []string{"a\n", "+", "b\n"} {
+		if w == "+" {
+			clock.Add(time.Hour)
+
+			continue
+		}
+
+		_, err := io.WriteString(file, w)
+		assert.NoError(t, err)
+	}
+
+	assert.NoError(t, file.Close())
+
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+
+	var names []string
+
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+
+	wantNames := append(slices.Clone(decoys), "app-2006-01-02T16-04-05.000.log.gz", "app.log")
+	slices.Sort(wantNames)
+
+	assert.Equal(t, wantNames, names)
+
+	for _, name := range decoys {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		assert.NoError(t, err)
+		assert.Equal(t, "decoy\n", string(data))
+	}
+}
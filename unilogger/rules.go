@@ -0,0 +1,152 @@
+package unilogger
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	logContext "slog-test/unilogger/context"
+)
+
+// LevelRule sets the level of the records whose logger matches all conditions,
+// like "debug when tenant=acme" or "drop info from logger=healthcheck",
+// which is the warn level for the healthcheck logger.
+type LevelRule struct {
+	// Match maps the attribute keys to the values, compared in their text form.
+	// The attributes are taken from WithRuleAttrs in the context and from
+	// the top level With attributes. The "logger" key matches the Named name,
+	// "db.*" matches the descendants of db.
+	Match map[string]string
+	Level Level
+}
+
+// ruleSet holds the level rules shared by a handler and its clones.
+type ruleSet struct {
+	rules atomic.Pointer[[]LevelRule]
+}
+
+func (rs *ruleSet) set(rules []LevelRule) {
+	rules = slices.Clone(rules)
+	rs.rules.Store(&rules)
+}
+
+func (rs *ruleSet) get() []LevelRule {
+	if rules := rs.rules.Load(); rules != nil {
+		return *rules
+	}
+
+	return nil
+}
+
+// WithRuleAttrs returns a context with the attributes matched by the level
+// rules, in addition to the ones already in ctx. They are not written to the records.
+func WithRuleAttrs(ctx context.Context, args ...any) context.Context {
+	attrs := slog.Group("", args...).Value.Group()
+
+	if prev := logContext.GetRuleAttrsContext(ctx); len(prev) > 0 {
+		attrs = append(slices.Clip(prev), attrs...)
+	}
+
+	return logContext.SetRuleAttrsContext(ctx, attrs)
+}
+
+// SetLevelRules replaces the level rules of the handler and of the handlers
+// derived from it. The first matching rule wins.
+func (h *SlogHandler) SetLevelRules(rules ...LevelRule) {
+	h.rules.set(rules)
+}
+
+// ruleLevel returns the level of the first rule matching the handler and ctx.
+func (h *SlogHandler) ruleLevel(ctx context.Context) (slog.Level, bool) {
+	rules := h.rules.get()
+	if len(rules) == 0 {
+		return 0, false
+	}
+
+	var ctxAttrs []slog.Attr
+	if ctx != nil {
+		ctxAttrs = logContext.GetRuleAttrsContext(ctx)
+	}
+
+	for _, rule := range rules {
+		if h.ruleMatches(rule, ctxAttrs) {
+			return rule.Level.Level(), true
+		}
+	}
+
+	return 0, false
+}
+
+func (h *SlogHandler) ruleMatches(rule LevelRule, ctxAttrs []slog.Attr) bool {
+	for key, want := range rule.Match {
+		if key == "logger" {
+			if !nameMatches(h.name, want) {
+				return false
+			}
+
+			continue
+		}
+
+		v, ok := h.ruleAttr(key, ctxAttrs)
+		if !ok || !valueEquals(v, want) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ruleAttr returns the last value of the key from ctx, or from the top level With attrs.
+func (h *SlogHandler) ruleAttr(key string, ctxAttrs []slog.Attr) (slog.Value, bool) {
+	for i := len(ctxAttrs) - 1; i >= 0; i-- {
+		if ctxAttrs[i].Key == key {
+			return ctxAttrs[i].Value.Resolve(), true
+		}
+	}
+
+	for i := len(h.attrs) - 1; i >= 0; i-- {
+		if len(h.attrs[i].groups) > 0 {
+			continue
+		}
+
+		attrs := h.attrs[i].attrs
+		for j := len(attrs) - 1; j >= 0; j-- {
+			if attrs[j].Key == key {
+				return attrs[j].Value.Resolve(), true
+			}
+		}
+	}
+
+	return slog.Value{}, false
+}
+
+func nameMatches(name, pattern string) bool {
+	if base, ok := splitPattern(pattern); ok {
+		return name != "" && (base == "" || strings.HasPrefix(name, base+"."))
+	}
+
+	return name == pattern
+}
+
+// valueEquals compares the text form of v with s, without allocations for the scalar kinds.
+func valueEquals(v slog.Value, s string) bool {
+	var b [64]byte
+
+	switch v.Kind() {
+	case slog.KindString:
+		return v.String() == s
+	case slog.KindInt64:
+		return string(strconv.AppendInt(b[:0], v.Int64(), 10)) == s
+	case slog.KindUint64:
+		return string(strconv.AppendUint(b[:0], v.Uint64(), 10)) == s
+	case slog.KindBool:
+		return string(strconv.AppendBool(b[:0], v.Bool())) == s
+	case slog.KindFloat64:
+		return string(strconv.AppendFloat(b[:0], v.Float64(), 'g', -1, 64)) == s
+	default:
+		return v.String() == s
+	}
+}
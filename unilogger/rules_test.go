@@ -0,0 +1,186 @@
+package unilogger_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+
+	"slog-test/unilogger"
+)
+
+func Test_LevelRules(t *testing.T) {
+	t.Parallel()
+
+	type meta struct {
+		name    string
+		enabled bool
+	}
+
+	type args struct {
+		rules []unilogger.LevelRule
+	}
+
+	type wants struct {
+		// messages of the written records
+		msgs []string
+	}
+
+	// each logfn writes a record named by the logger and its level
+	logfns := []func(root *unilogger.Logger){
+		func(root *unilogger.Logger) {
+			root.Debug("root debug")
+		},
+		func(root *unilogger.Logger) {
+			root.With("tenant", "acme").Debug("acme debug")
+		},
+		func(root *unilogger.Logger) {
+			ctx := unilogger.WithRuleAttrs(context.Background(), "tenant", "acme")
+			root.DebugContext(ctx, "acme ctx debug")
+		},
+		func(root *unilogger.Logger) {
+			root.Named("db").With("user_id", 42).Log(context.Background(), unilogger.LevelTrace.Level(), "db user trace")
+		},
+		func(root *unilogger.Logger) {
+			root.Named("db").Named("pool").With("user_id", 42).Log(context.Background(), unilogger.LevelTrace.Level(), "db pool user trace")
+		},
+		func(root *unilogger.Logger) {
+			root.Named("healthcheck").Info("healthcheck info")
+		},
+		func(root *unilogger.Logger) {
+			root.Named("healthcheck").Warn("healthcheck warn")
+		},
+	}
+
+	tests := []struct {
+		meta  meta
+		args  args
+		wants wants
+	}{
+		{
+			meta: meta{
+				name:    "no rules",
+				enabled: true,
+			},
+			wants: wants{
+				msgs: []string{"healthcheck info", "healthcheck warn"},
+			},
+		},
+		{
+			meta: meta{
+				name:    "debug when tenant is acme",
+				enabled: true,
+			},
+			args: args{
+				rules: []unilogger.LevelRule{
+					{Match: map[string]string{"tenant": "acme"}, Level: unilogger.LevelDebug},
+				},
+			},
+			wants: wants{
+				msgs: []string{"acme debug", "acme ctx debug", "healthcheck info", "healthcheck warn"},
+			},
+		},
+		{
+			meta: meta{
+				name:    "trace when logger is db and user is 42",
+				enabled: true,
+			},
+			args: args{
+				rules: []unilogger.LevelRule{
+					{Match: map[string]string{"logger": "db", "user_id": "42"}, Level: unilogger.LevelTrace},
+					{Match: map[string]string{"logger": "db.*", "user_id": "42"}, Level: unilogger.LevelTrace},
+				},
+			},
+			wants: wants{
+				msgs: []string{"db user trace", "db pool user trace", "healthcheck info", "healthcheck warn"},
+			},
+		},
+		{
+			meta: meta{
+				name:    "drop info from healthcheck",
+				enabled: true,
+			},
+			args: args{
+				rules: []unilogger.LevelRule{
+					{Match: map[string]string{"logger": "healthcheck"}, Level: unilogger.LevelWarn},
+				},
+			},
+			wants: wants{
+				msgs: []string{"healthcheck warn"},
+			},
+		},
+		{
+			meta: meta{
+				name:    "first matching rule wins",
+				enabled: true,
+			},
+			args: args{
+				rules: []unilogger.LevelRule{
+					{Match: map[string]string{"logger": "healthcheck"}, Level: unilogger.LevelError},
+					{Match: map[string]string{}, Level: unilogger.LevelDebug},
+				},
+			},
+			wants: wants{
+				msgs: []string{"root debug", "acme debug", "acme ctx debug"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		if !tt.meta.enabled {
+			continue
+		}
+
+		t.Run(tt.meta.name, func(t *testing.T) {
+			buf := bytes.NewBuffer([]byte{})
+
+			root := unilogger.NewLogger(unilogger.Options{
+				Format:   unilogger.FormatLogfmt,
+				Output:   buf,
+				TimeFunc: stubTimeFn,
+			})
+
+			root.SetLevelRules(tt.args.rules...)
+
+			var msgs []string
+
+			for _, logfn := range logfns {
+				buf.Reset()
+				logfn(root)
+
+				if _, msg, ok := strings.Cut(buf.String(), `msg="`); ok {
+					msg, _, _ = strings.Cut(msg, `"`)
+					msgs = append(msgs, msg)
+				}
+			}
+
+			assert.Equal(t, tt.wants.msgs, msgs)
+		})
+	}
+}
+
+func Test_LevelRules_HotSwap(t *testing.T) {
+	t.Parallel()
+
+	buf := bytes.NewBuffer([]byte{})
+
+	root := unilogger.NewLogger(unilogger.Options{
+		Format:   unilogger.FormatLogfmt,
+		Output:   buf,
+		TimeFunc: stubTimeFn,
+	})
+	acme := root.With("tenant", "acme")
+
+	acme.Debug("before")
+
+	root.SetLevelRules(unilogger.LevelRule{Match: map[string]string{"tenant": "acme"}, Level: unilogger.LevelDebug})
+	acme.Debug("with rule")
+
+	root.SetLevelRules()
+	acme.Debug("after")
+
+	assert.Equal(t, `level=debug msg="with rule" tenant=acme time=2006-01-02T15:04:05Z`+"\n", buf.String())
+	assert.Equal(t, 0, len(root.LevelRules()))
+}
@@ -0,0 +1,122 @@
+package unilogger
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+)
+
+// Sink is one destination of the records, see Options.Sinks.
+type Sink struct {
+	// Output is os.Stdout if nil
+	Output io.Writer
+	// Level of the sink, it only drops the records passed by the logger level
+	Level slog.Leveler
+	// Format of the records, FormatJSON by default
+	Format Format
+	// Syslog is the header of FormatSyslog
+	Syslog SyslogOptions
+	// Journal are the fields of FormatJournal
+	Journal JournalOptions
+	// ReplaceAttr replaces the one of the logger
+	ReplaceAttr func(groups []string, a slog.Attr) slog.Attr
+	// Async writes the records in the background,
+	// so a slow sink does not hold up the others
+	Async *AsyncOptions
+}
+
+// WithSinks returns a handler which writes every record to each of the
+// sinks accepting its level, in its own format. The level, the named
+// levels, the rules and the source policy stay shared and apply to all
+// sinks, the layout, stack and key options are copied from h. A sink
+// failing to write doesn't stop the others, Handle returns the joined errors.
+func (h *SlogHandler) WithSinks(sinks ...Sink) *SlogHandler {
+	h2 := h.clone()
+	h2.sinks = make([]*SlogHandler, 0, len(sinks))
+
+	for _, sink := range sinks {
+		h2.sinks = append(h2.sinks, h.newSink(sink))
+	}
+
+	return h2
+}
+
+func (h *SlogHandler) newSink(sink Sink) *SlogHandler {
+	if sink.Output == nil {
+		sink.Output = os.Stdout
+	}
+
+	s := h.clone()
+	s.sinks = nil
+	s.out = &output{w: sink.Output}
+	s.format = sink.Format
+	s.color = sink.Format == FormatConsole && isTerminal(sink.Output) && os.Getenv("NO_COLOR") == ""
+	s.threshold = sink.Level
+
+	if sink.ReplaceAttr != nil {
+		opts := *h.opts
+		opts.ReplaceAttr = sink.ReplaceAttr
+		s.opts = &opts
+	}
+
+	switch sink.Format {
+	case FormatSyslog:
+		s = s.WithSyslog(sink.Syslog)
+	case FormatJournal:
+		s = s.WithJournal(sink.Journal)
+	}
+
+	if sink.Async != nil {
+		s = s.WithAsync(*sink.Async)
+	}
+
+	return s
+}
+
+// sinkEnabled reports whether some sink accepts the level.
+func (h *SlogHandler) sinkEnabled(level slog.Level) bool {
+	if len(h.sinks) == 0 {
+		return true
+	}
+
+	for _, sink := range h.sinks {
+		if sink.accepts(level) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (h *SlogHandler) accepts(level slog.Level) bool {
+	return h.threshold == nil || level >= h.threshold.Level()
+}
+
+func (h *SlogHandler) handleSinks(r slog.Record, trace []uintptr) error {
+	var errs []error
+
+	for _, sink := range h.sinks {
+		if !sink.accepts(r.Level) {
+			continue
+		}
+
+		if err := sink.handle(r, trace); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (h *SlogHandler) eachSink(fn func(*SlogHandler) error) error {
+	var errs []error
+
+	for _, sink := range h.sinks {
+		if err := fn(sink); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
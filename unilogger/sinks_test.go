@@ -0,0 +1,90 @@
+package unilogger_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+
+	"slog-test/unilogger"
+)
+
+func Test_Sinks(t *testing.T) {
+	t.Parallel()
+
+	stdout := bytes.NewBuffer([]byte{})
+	file := bytes.NewBuffer([]byte{})
+	network := bytes.NewBuffer([]byte{})
+
+	logger := unilogger.NewLogger(unilogger.Options{
+		Level:    slog.Level(unilogger.LevelDebug),
+		TimeFunc: stubTimeFn,
+		Sinks: []unilogger.Sink{
+			{Output: stdout, Level: unilogger.LevelInfo},
+			{Output: file, Level: unilogger.LevelDebug, Format: unilogger.FormatConsole},
+			{
+				Output: network,
+				Level:  unilogger.LevelError,
+				Format: unilogger.FormatLogfmt,
+				ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+					if a.Key == "secret" {
+						a.Value = slog.StringValue("***")
+					}
+
+					return a
+				},
+			},
+		},
+	})
+
+	db := logger.Named("db").With("secret", "stub").WithGroup("query")
+
+	logger.Trace("trace")
+	db.Debug("debug", "rows", 1)
+	db.Info("info", "rows", 2)
+	db.Error("error", "rows", 3)
+
+	assert.Equal(t, ""+
+		`{"level":"info","logger":"db","msg":"info","secret":"stub","query":{"rows":2},"time":"2006-01-02T15:04:05Z"}`+"\n"+
+		`{"level":"error","logger":"db","msg":"error","secret":"stub","query":{"rows":3},"time":"2006-01-02T15:04:05Z"}`+"\n",
+		stdout.String())
+	assert.Equal(t, ""+
+		`2006-01-02T15:04:05Z DEBUG db debug secret=stub query.rows=1`+"\n"+
+		`2006-01-02T15:04:05Z INFO  db info secret=stub query.rows=2`+"\n"+
+		`2006-01-02T15:04:05Z ERROR db error secret=stub query.rows=3`+"\n",
+		file.String())
+	assert.Equal(t, `level=error logger=db msg=error secret=*** query.rows=3 time=2006-01-02T15:04:05Z`+"\n",
+		network.String())
+
+	assert.True(t, logger.Enabled(context.Background(), slog.Level(unilogger.LevelDebug)))
+	assert.False(t, logger.Enabled(context.Background(), slog.Level(unilogger.LevelTrace)))
+}
+
+func Test_Sinks_Isolation(t *testing.T) {
+	t.Parallel()
+
+	buf := bytes.NewBuffer([]byte{})
+	slow := newGateWriter()
+
+	h := unilogger.NewLogfmtHandler(nil, nil, stubTimeFn).WithSinks(
+		unilogger.Sink{Output: errWriter{}},
+		unilogger.Sink{Output: slow, Format: unilogger.FormatLogfmt, Async: &unilogger.AsyncOptions{QueueSize: 1, Policy: unilogger.AsyncDropNewest}},
+		unilogger.Sink{Output: buf, Format: unilogger.FormatLogfmt},
+	)
+
+	assert.EqualError(t, h.Handle(context.Background(), newStubRecord()), "stub error")
+	<-slow.entered
+
+	// the slow sink holds the first record, queues the second and drops the third
+	assert.EqualError(t, h.WithGroup("g").Handle(context.Background(), newStubRecord()), "stub error")
+	assert.EqualError(t, h.WithGroup("g").Handle(context.Background(), newStubRecord()), "stub error")
+	assert.Equal(t, 3, bytes.Count(buf.Bytes(), []byte("\n")))
+
+	close(slow.release)
+
+	assert.NoError(t, h.Close())
+	assert.Equal(t, uint64(1), h.DroppedRecords())
+	assert.Equal(t, 2, len(slow.msgs()))
+}
@@ -1,14 +1,12 @@
 package unilogger
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
-	"fmt"
 	"io"
 	"log/slog"
 	"runtime"
-	"strings"
+	"slices"
+	"strconv"
 	"sync"
 	"time"
 
@@ -28,150 +26,842 @@ func NewSlogLogger(w io.Writer, opts *slog.HandlerOptions) *WrappedLogger {
 	}
 }
 
+// Format is the encoding of the records.
+type Format int
+
+const (
+	// FormatJSON writes JSON lines, see DefaultLayout
+	FormatJSON Format = iota
+	// FormatConsole writes colored human-readable lines, see NewConsoleHandler
+	FormatConsole
+	// FormatLogfmt writes key=value lines, see NewLogfmtHandler
+	FormatLogfmt
+	// FormatSyslog writes RFC 5424 messages, see NewSyslogHandler
+	FormatSyslog
+	// FormatJournal writes journald native protocol entries, see NewJournalHandler
+	FormatJournal
+)
+
 var _ slog.Handler = (*SlogHandler)(nil)
 
+// SlogHandler writes records as JSON lines in the head/body/foot layout,
+// see DefaultLayout. Records are encoded in one pass straight to a pooled buffer.
+// Attributes keep their insertion order: attrs from WithAttrs come first,
+// then the record attrs, nested into the groups from WithGroup.
 type SlogHandler struct {
-	slog.Handler
+	opts *slog.HandlerOptions
 
-	w io.Writer
-	b *bytes.Buffer
-	m *sync.Mutex
+	// output, shared with the clones
+	out *output
+
+	timeFn    func(t time.Time) time.Time
+	layout    Layout
+	stackOpts StackOptions
+	format    Format
+	color     bool
+
+	keyPolicy    KeyPolicy
+	renamePrefix string
+
+	// name is taken from the attribute added by Logger.Named
+	name string
+	// source policy, shared with the clones
+	source *sourceSwitch
+	// level rules, shared with the clones
+	rules *ruleSet
+	// levels of the named loggers, level is the one of name
+	levels *levelTree
+	level  slog.Leveler
+	// attrs from WithAttrs, already encoded
+	preformatted []byte
+	// all groups from WithGroup
+	groups []string
+	// number of groups opened in preformatted
+	nOpenGroups int
+
+	// attrs from WithAttrs as is, to resolve key collisions
+	attrs []groupedAttrs
+	// keys of the innermost object opened in preformatted
+	preKeys []string
+	// keys of the top level object in preformatted
+	rootKeys []string
+	// preformatted attrs have colliding keys, the records
+	// are written with the key policy applied
+	preCollides bool
+
+	// sinks get the records instead of out, see WithSinks
+	sinks []*SlogHandler
+	// threshold is the level of a sink
+	threshold slog.Leveler
+	// header of FormatSyslog
+	syslog *syslogHeader
+	// fields of FormatJournal
+	journal *JournalOptions
+	// onError gets the errors of Handle, see WithErrorHandler
+	onError func(err error)
+}
 
-	timeFn func(t time.Time) time.Time
+// Enabled reports whether the level is at or above the level of the handler:
+// the first matching level rule, else the level of the named logger.
+// The level set by WithLevel in ctx can only lower it.
+func (h *SlogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.enabled(ctx, level) && h.sinkEnabled(level)
 }
 
-func NewSlogHandler(handler slog.Handler) *SlogHandler {
-	return &SlogHandler{
-		Handler: handler,
+func (h *SlogHandler) enabled(ctx context.Context, level slog.Level) bool {
+	minLevel := h.minLevel(ctx)
+
+	if ctx != nil {
+		if ctxLevel, ok := logContext.GetLevelContext(ctx); ok {
+			minLevel = min(minLevel, ctxLevel)
+		}
 	}
+
+	return level >= minLevel
 }
 
-func (h *SlogHandler) Handle(ctx context.Context, r slog.Record) error {
-	h.m.Lock()
+func (h *SlogHandler) minLevel(ctx context.Context) slog.Level {
+	if minLevel, ok := h.ruleLevel(ctx); ok {
+		return minLevel
+	}
+
+	if h.level != nil {
+		return h.level.Level()
+	}
 
-	defer func() {
-		h.b.Reset()
-		h.m.Unlock()
-	}()
+	if h.opts.Level != nil {
+		return h.opts.Level.Level()
+	}
 
-	var (
-		fields   = make(map[string]interface{}, r.NumAttrs())
-		out      []byte
-		tracePtr *string
-	)
+	return slog.LevelInfo
+}
+
+func (h *SlogHandler) Handle(ctx context.Context, r slog.Record) error {
+	var trace []uintptr
 
 	isCustom := logContext.GetCustomKeyContext(ctx)
 	if isCustom {
-		r.PC, _, _, _ = runtime.Caller(4)
+		var pcs [1]uintptr
+		// skip [runtime.Callers, Handle, slog.Logger.log, slog.Logger.Log, unilogger wrapper]
+		runtime.Callers(5, pcs[:])
+		r.PC = pcs[0]
 
-		tracePtr = logContext.GetStackTraceContext(ctx)
+		trace = logContext.GetStackTraceContext(ctx)
 	}
 
-	if err := h.Handler.Handle(ctx, r); err != nil {
-		return err
-	}
+	var err error
 
-	attrs := map[string]any{}
-	if err := json.Unmarshal(h.b.Bytes(), &attrs); err != nil {
-		return err
+	if len(h.sinks) > 0 {
+		err = h.handleSinks(r, trace)
+	} else {
+		err = h.handle(r, trace)
 	}
 
-	for k, v := range attrs {
-		fields[k] = v
+	if err != nil && h.onError != nil {
+		h.onError(err)
 	}
 
-	// HEAD start
-	var headLogFields []string
-	lvl := fmt.Sprintf(`"level":"%s"`, strings.ToLower(Level(r.Level).String()))
-	time := fmt.Sprintf(`"time":"%s"`, h.timeFn(r.Time).Format(time.RFC3339))
-	msg := fmt.Sprintf(`"msg":"%s"`, r.Message)
+	return err
+}
 
-	headLogFields = append(headLogFields, lvl)
+func (h *SlogHandler) handle(r slog.Record, trace []uintptr) error {
+	s := h.newHandleState(newBuffer())
+	defer s.free()
 
-	// if logger was named
-	loggerName, ok := fields["logger"]
-	if ok {
-		name := fmt.Sprintf(`"logger":"%s"`, loggerName)
-		headLogFields = append(headLogFields, name)
+	s.record = r
+	s.hasRecord = true
+	s.trace = trace
+	s.resolveRecordAttrs()
 
-		delete(fields, "logger")
+	if h.preCollides {
+		s.resolveKeys()
+	} else {
+		s.trackKeys()
 	}
 
-	headLogFields = append(headLogFields, msg)
+	s.appendRecord()
 
-	// FOOT start
-	var footLogFields []string
+	if s.collided {
+		// some key is taken twice, write the record again with the key policy
+		*s.buf = (*s.buf)[:0]
+		s.resolveKeys()
+		s.appendRecord()
+	}
 
-	// if logger was named
-	if tracePtr != nil {
-		trace := fmt.Sprintf(`"trace":"%s"`, *tracePtr)
-		footLogFields = append(footLogFields, trace)
+	if err := h.out.write(*s.buf, r.Level); err != nil {
+		return err
 	}
 
-	footLogFields = append(footLogFields, time)
+	return s.keyErr
+}
 
-	fieldSource, ok := fields["source"]
-	if ok {
-		src := fmt.Sprintf(`"source":"%s"`, fieldSource)
-		headLogFields = append(headLogFields, src)
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := h.withAttrs(attrs)
 
-		delete(fields, "source")
+	if h2 != h && len(h.sinks) > 0 {
+		h2.sinks = make([]*SlogHandler, len(h.sinks))
+		for i, sink := range h.sinks {
+			h2.sinks[i] = sink.withAttrs(attrs)
+		}
 	}
 
-	b, err := json.Marshal(fields)
-	if err != nil {
-		return err
+	return h2
+}
+
+func (h *SlogHandler) withAttrs(attrs []slog.Attr) *SlogHandler {
+	if len(attrs) < 1 {
+		return h
 	}
 
-	rawHeadLogFields := strings.Join(headLogFields, ",")
-	rawfootLogFields := strings.Join(footLogFields, ",")
+	h2 := h.clone()
+
+	// named logger attribute goes to the head of the record
+	attrs = slices.DeleteFunc(slices.Clone(attrs), func(a slog.Attr) bool {
+		if a.Value.Kind() != slog.KindAny {
+			return false
+		}
 
-	out = append(out, '{')
+		name, ok := a.Value.Any().(loggerName)
+		if ok {
+			h2.name = string(name)
+			h2.level = h.levels.leveler(h2.name)
+		}
 
-	out = append(out, []byte(rawHeadLogFields)...)
+		return ok
+	})
 
-	out = append(out, ',')
+	s := h2.newHandleState((*buffer)(&h2.preformatted))
+	defer s.prefix.Free()
+
+	resolved := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		resolved[i] = s.resolveAttr(h2.groups, a)
+	}
 
-	if len(fields) > 0 {
-		out = append(out, b[1:len(b)-1]...)
-		out = append(out, ',')
+	if len(attrs) > 0 {
+		h2.attrs = append(slices.Clip(h.attrs), groupedAttrs{groups: h2.groups, attrs: attrs, resolved: resolved})
 	}
 
-	out = append(out, []byte(rawfootLogFields)...)
+	s.trackKeys()
+	defer s.freeKeys()
 
-	out = append(out, '}')
+	pos := len(*s.buf)
+	s.openGroups()
 
-	h.w.Write(append(out, "\n"...))
+	written := false
+
+	for _, a := range resolved {
+		if s.appendAttr(a) {
+			written = true
+		}
+	}
+
+	if written {
+		keys := (*s.keys)[s.objStart:]
+
+		if h.nOpenGroups == len(h2.groups) {
+			h2.preKeys = append(slices.Clip(h.preKeys), keys...)
+		} else {
+			h2.preKeys = slices.Clone(keys)
+		}
+
+		switch {
+		case len(h2.groups) == 0:
+			h2.rootKeys = h2.preKeys
+		case h.nOpenGroups == 0:
+			h2.rootKeys = append(slices.Clip(h.rootKeys), h2.groups[0])
+		}
+
+		h2.nOpenGroups = len(h2.groups)
+	} else {
+		*s.buf = (*s.buf)[:pos]
+	}
 
-	return nil
+	// the name may take the key of an attribute
+	if s.collided || slices.ContainsFunc(h2.rootKeys, s.isReserved) {
+		h2.preCollides = true
+	}
+
+	return h2
 }
 
-func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
-	if len(attrs) < 1 {
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
 		return h
 	}
 
-	h2 := *h
-	h2.Handler = h.Handler.WithAttrs(attrs)
+	h2 := h.clone()
+	h2.groups = append(h2.groups, name)
 
-	return &h2
+	if len(h.sinks) > 0 {
+		h2.sinks = make([]*SlogHandler, len(h.sinks))
+		for i, sink := range h.sinks {
+			h2.sinks[i] = sink.WithGroup(name).(*SlogHandler)
+		}
+	}
+
+	return h2
 }
 
-func (h *SlogHandler) WithGroup(name string) slog.Handler {
+// WithLayout returns a handler which writes the built-in fields by the layout.
+func (h *SlogHandler) WithLayout(layout Layout) *SlogHandler {
+	h2 := h.clone()
+	h2.layout = layout
+
+	return h2
+}
+
+// WithErrorHandler returns a handler which passes the errors of Handle to fn,
+// as slog.Logger drops them: the write errors and ErrDuplicateKey of KeyError.
+func (h *SlogHandler) WithErrorHandler(fn func(err error)) *SlogHandler {
+	h2 := h.clone()
+	h2.onError = fn
+
+	return h2
+}
+
+// WithStackOptions returns a handler which writes the stack traces by the options.
+func (h *SlogHandler) WithStackOptions(opts StackOptions) *SlogHandler {
+	h2 := h.clone()
+	h2.stackOpts = opts
+
+	return h2
+}
+
+func (h *SlogHandler) clone() *SlogHandler {
 	h2 := *h
-	h2.Handler = h.Handler.WithGroup(name)
+	h2.preformatted = slices.Clip(h.preformatted)
+	h2.groups = slices.Clip(h.groups)
 
 	return &h2
 }
 
 func NewHandler(out io.Writer, opts *slog.HandlerOptions, timeFn func(t time.Time) time.Time) *SlogHandler {
-	b := new(bytes.Buffer)
+	if opts == nil {
+		opts = &slog.HandlerOptions{}
+	}
+
+	if timeFn == nil {
+		timeFn = func(t time.Time) time.Time {
+			return t
+		}
+	}
 
 	return &SlogHandler{
-		Handler: slog.NewJSONHandler(b, opts),
-		b:       b,
-		m:       &sync.Mutex{},
-		w:       out,
-		timeFn:  timeFn,
+		opts:   opts,
+		out:    &output{w: out},
+		timeFn: timeFn,
+		layout: DefaultLayout(),
+
+		renamePrefix: DefaultRenamePrefix,
+
+		source: newSourceSwitch(sourcePolicyFor(opts.AddSource)),
+		rules:  &ruleSet{},
+		levels: newLevelTree(opts.Level),
+	}
+}
+
+// sourceAttr returns the caller passed through ReplaceAttr,
+// computed once per record.
+func (s *handleState) sourceAttr(pc uintptr) slog.Attr {
+	if s.hasSource {
+		return s.source
+	}
+
+	fs := runtime.CallersFrames([]uintptr{pc})
+	f, _ := fs.Next()
+
+	a := slog.Any(slog.SourceKey, &slog.Source{
+		Function: f.Function,
+		File:     f.File,
+		Line:     f.Line,
+	})
+
+	if rep := s.h.opts.ReplaceAttr; rep != nil {
+		a = rep(nil, a)
+		a.Value = a.Value.Resolve()
+	}
+
+	s.source = a
+	s.hasSource = true
+
+	return a
+}
+
+// handleState holds state for a single call to Handle or WithAttrs.
+type handleState struct {
+	h   *SlogHandler
+	buf *buffer
+	// groups passed to ReplaceAttr, relative to the top level
+	groups []string
+	// flattened groups for the key=value formats, like "group.inner."
+	prefix *buffer
+
+	// record and its trace, unset in WithAttrs
+	record    slog.Record
+	hasRecord bool
+	trace     []uintptr
+	// record attrs after ReplaceAttr, see resolveAttr
+	attrs *[]slog.Attr
+	// source after ReplaceAttr, see sourceAttr
+	source    slog.Attr
+	hasSource bool
+
+	// keys of the open objects, tracked to find collisions
+	keys *[]string
+	// start of the current object keys
+	objStart int
+	// depth of the current object, 0 is the innermost preformatted one
+	depth    int
+	collided bool
+
+	// body and built-in field values with the key policy applied
+	body      *attrNode
+	overrides map[string]*attrNode
+	keyErr    error
+}
+
+func (h *SlogHandler) newHandleState(buf *buffer) handleState {
+	return handleState{
+		h:      h,
+		buf:    buf,
+		groups: slices.Clip(h.groups),
+		prefix: newBuffer(),
+	}
+}
+
+func (s *handleState) free() {
+	s.buf.Free()
+	s.prefix.Free()
+	s.freeKeys()
+	s.freeAttrs()
+}
+
+func (s *handleState) appendRecord() {
+	switch s.h.format {
+	case FormatConsole:
+		s.appendConsoleRecord()
+	case FormatSyslog:
+		s.appendSyslogRecord()
+	case FormatJournal:
+		s.appendJournalRecord()
+	default:
+		s.appendLayoutRecord()
+	}
+}
+
+// appendLayoutRecord writes the record in the head/body/foot layout.
+func (s *handleState) appendLayoutRecord() {
+	if s.h.format == FormatJSON {
+		*s.buf = append(*s.buf, '{')
+	}
+
+	// HEAD start
+	for _, f := range s.h.layout.Head {
+		s.appendField(f)
 	}
+
+	// BODY start
+	s.appendBody()
+
+	// FOOT start
+	for _, f := range s.h.layout.Foot {
+		s.appendField(f)
+	}
+
+	if s.h.format == FormatJSON {
+		*s.buf = append(*s.buf, '}')
+	}
+
+	*s.buf = append(*s.buf, '\n')
+}
+
+// appendBody writes the preformatted attrs followed by the record attrs.
+func (s *handleState) appendBody() {
+	if s.body != nil {
+		s.appendNodes(s.body.children)
+
+		return
+	}
+
+	if len(s.h.preformatted) > 0 {
+		s.appendSep()
+		*s.buf = append(*s.buf, s.h.preformatted...)
+	}
+
+	nOpenGroups := s.h.nOpenGroups
+
+	if len(*s.attrs) > 0 {
+		pos := len(*s.buf)
+		s.openGroups()
+
+		written := false
+
+		for _, a := range *s.attrs {
+			if s.appendAttr(a) {
+				written = true
+			}
+		}
+
+		if written {
+			nOpenGroups = len(s.h.groups)
+		} else {
+			*s.buf = (*s.buf)[:pos]
+		}
+	}
+
+	s.closeGroups(nOpenGroups)
+}
+
+// appendSep writes the separator unless the key starts the line or the object.
+func (s *handleState) appendSep() {
+	n := len(*s.buf)
+	if n == 0 {
+		return
+	}
+
+	if s.h.format != FormatJSON {
+		*s.buf = append(*s.buf, ' ')
+	} else if (*s.buf)[n-1] != '{' {
+		*s.buf = append(*s.buf, ',')
+	}
+}
+
+// appendKey writes the separator if needed followed by the key.
+func (s *handleState) appendKey(key string) {
+	s.appendSep()
+
+	if s.h.format != FormatJSON {
+		s.appendTextKey(key)
+
+		return
+	}
+
+	*s.buf = appendJSONString(*s.buf, key)
+	*s.buf = append(*s.buf, ':')
+}
+
+// appendTextKey writes the key prefixed with the flattened groups.
+func (s *handleState) appendTextKey(key string) {
+	s.setColor(colorDim)
+
+	if needsQuoting(key) || needsQuoting(string(*s.prefix)) {
+		*s.buf = appendTextString(*s.buf, string(*s.prefix)+key)
+	} else {
+		*s.buf = append(*s.buf, *s.prefix...)
+		*s.buf = append(*s.buf, key...)
+	}
+
+	*s.buf = append(*s.buf, '=')
+	s.resetColor()
+}
+
+// appendString writes the string value in the handler format.
+func (s *handleState) appendString(v string) {
+	if s.h.format == FormatJSON {
+		*s.buf = appendJSONString(*s.buf, v)
+	} else {
+		*s.buf = appendTextString(*s.buf, v)
+	}
+}
+
+func (s *handleState) appendValue(v slog.Value) {
+	if s.h.format == FormatJSON {
+		*s.buf = appendJSONValue(*s.buf, v)
+	} else {
+		*s.buf = appendTextValue(*s.buf, v)
+	}
+}
+
+// appendTime writes the record time, transformed by timeFn.
+func (s *handleState) appendTime(t time.Time) {
+	if s.h.format == FormatJSON {
+		*s.buf = append(*s.buf, '"')
+	}
+
+	*s.buf = s.h.timeFn(t).AppendFormat(*s.buf, time.RFC3339)
+
+	if s.h.format == FormatJSON {
+		*s.buf = append(*s.buf, '"')
+	}
+}
+
+// openGroups opens the groups which are not opened in preformatted attrs yet.
+// The key=value formats put all of them to the prefix instead.
+func (s *handleState) openGroups() {
+	for _, g := range s.h.groups[s.h.nOpenGroups:] {
+		s.checkKey(g)
+		s.pushKeys()
+	}
+
+	if s.h.format != FormatJSON {
+		*s.prefix = (*s.prefix)[:0]
+
+		for _, g := range s.h.groups {
+			*s.prefix = append(*s.prefix, g...)
+			*s.prefix = append(*s.prefix, '.')
+		}
+
+		return
+	}
+
+	for _, g := range s.h.groups[s.h.nOpenGroups:] {
+		s.appendKey(g)
+		*s.buf = append(*s.buf, '{')
+	}
+}
+
+// closeGroups closes n groups opened by the preformatted attrs and openGroups.
+func (s *handleState) closeGroups(n int) {
+	if s.h.format != FormatJSON {
+		*s.prefix = (*s.prefix)[:0]
+
+		return
+	}
+
+	for range n {
+		*s.buf = append(*s.buf, '}')
+	}
+}
+
+// openGroup starts the group value of an attribute.
+func (s *handleState) openGroup(name string) {
+	s.groups = append(s.groups, name)
+
+	if s.h.format == FormatJSON {
+		s.appendKey(name)
+		*s.buf = append(*s.buf, '{')
+	} else {
+		*s.prefix = append(*s.prefix, name...)
+		*s.prefix = append(*s.prefix, '.')
+	}
+}
+
+func (s *handleState) closeGroup(name string) {
+	s.groups = s.groups[:len(s.groups)-1]
+
+	if s.h.format == FormatJSON {
+		*s.buf = append(*s.buf, '}')
+	} else {
+		*s.prefix = (*s.prefix)[:len(*s.prefix)-len(name)-1]
+	}
+}
+
+var attrsPool = sync.Pool{
+	New: func() any {
+		attrs := make([]slog.Attr, 0, 16)
+
+		return &attrs
+	},
+}
+
+// resolveRecordAttrs resolves the record attrs once, so that
+// the record can be written again with the key policy.
+func (s *handleState) resolveRecordAttrs() {
+	s.attrs = attrsPool.Get().(*[]slog.Attr)
+
+	s.record.Attrs(func(a slog.Attr) bool {
+		*s.attrs = append(*s.attrs, s.resolveAttr(s.h.groups, a))
+
+		return true
+	})
+}
+
+func (s *handleState) freeAttrs() {
+	if s.attrs != nil {
+		clear(*s.attrs)
+		*s.attrs = (*s.attrs)[:0]
+		attrsPool.Put(s.attrs)
+	}
+}
+
+// resolveAttr resolves the LogValuers and applies ReplaceAttr to the
+// attribute and the members of its groups. The groups are copied only
+// when something changes in them.
+func (s *handleState) resolveAttr(groups []string, a slog.Attr) slog.Attr {
+	a.Value = a.Value.Resolve()
+
+	if rep := s.h.opts.ReplaceAttr; rep != nil && a.Value.Kind() != slog.KindGroup {
+		a = rep(groups, a)
+		a.Value = a.Value.Resolve()
+	}
+
+	if a.Value.Kind() != slog.KindGroup || !s.needsResolve(a.Value.Group()) {
+		return a
+	}
+
+	if a.Key != "" {
+		groups = append(slices.Clip(groups), a.Key)
+	}
+
+	members := a.Value.Group()
+	resolved := make([]slog.Attr, len(members))
+
+	for i, m := range members {
+		resolved[i] = s.resolveAttr(groups, m)
+	}
+
+	a.Value = slog.GroupValue(resolved...)
+
+	return a
+}
+
+// needsResolve reports whether resolveAttr would change the group members.
+func (s *handleState) needsResolve(members []slog.Attr) bool {
+	if s.h.opts.ReplaceAttr != nil {
+		return true
+	}
+
+	for _, m := range members {
+		switch m.Value.Kind() {
+		case slog.KindLogValuer:
+			return true
+		case slog.KindGroup:
+			if s.needsResolve(m.Value.Group()) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// appendAttr writes the attribute, already resolved by resolveAttr,
+// and reports whether anything was written.
+func (s *handleState) appendAttr(a slog.Attr) bool {
+	// elide empty attrs
+	if a.Equal(slog.Attr{}) {
+		return false
+	}
+
+	if a.Value.Kind() != slog.KindGroup || a.Key != "" {
+		s.checkKey(a.Key)
+	}
+
+	if err, ok := AsError(a.Value); ok {
+		s.appendError(a.Key, err)
+
+		return true
+	}
+
+	if a.Value.Kind() != slog.KindGroup {
+		s.appendKey(a.Key)
+		s.appendValue(a.Value)
+
+		return true
+	}
+
+	attrs := a.Value.Group()
+	// elide empty groups
+	if len(attrs) == 0 {
+		return false
+	}
+
+	// inline groups with empty key
+	if a.Key == "" {
+		written := false
+
+		for _, ga := range attrs {
+			if s.appendAttr(ga) {
+				written = true
+			}
+		}
+
+		return written
+	}
+
+	pos := len(*s.buf)
+	s.openGroup(a.Key)
+	start := s.pushKeys()
+
+	written := false
+
+	for _, ga := range attrs {
+		if s.appendAttr(ga) {
+			written = true
+		}
+	}
+
+	s.popKeys(start)
+	s.closeGroup(a.Key)
+
+	if !written {
+		*s.buf = (*s.buf)[:pos]
+
+		return false
+	}
+
+	return true
+}
+
+// appendField writes the built-in field, if the record has it.
+func (s *handleState) appendField(f LayoutField) {
+	r := &s.record
+
+	if n, ok := s.overrides[f.key()]; ok {
+		n.key = f.key()
+		s.appendNode(n)
+
+		return
+	}
+
+	switch f.Field {
+	case FieldLevel:
+		s.appendKey(f.key())
+		s.appendString(Level(r.Level).String())
+	case FieldLogger:
+		// if logger was named
+		if s.h.name != "" {
+			s.appendKey(f.key())
+			s.appendString(s.h.name)
+		}
+	case FieldMessage:
+		s.appendKey(f.key())
+		s.appendString(r.Message)
+	case FieldSource:
+		if s.addSource() {
+			s.appendSource(f.key(), r.PC)
+		}
+	case FieldTrace:
+		if len(s.trace) > 0 {
+			s.appendKey(f.key())
+			s.appendStack(s.trace)
+		}
+	case FieldTime:
+		s.appendKey(f.key())
+		s.appendTime(r.Time)
+	}
+}
+
+// appendSource writes the caller under key. ReplaceAttr gets it
+// with slog.SourceKey and may change or drop the value.
+func (s *handleState) appendSource(key string, pc uintptr) {
+	a := s.sourceAttr(pc)
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+
+	s.appendKey(key)
+
+	src, ok := a.Value.Any().(*slog.Source)
+	if !ok {
+		s.appendValue(a.Value)
+
+		return
+	}
+
+	if s.h.format != FormatJSON {
+		*s.buf = appendTextString(*s.buf, src.File+":"+strconv.Itoa(src.Line))
+
+		return
+	}
+
+	*s.buf = append(*s.buf, '{')
+	s.appendKey("function")
+	*s.buf = appendJSONString(*s.buf, src.Function)
+	s.appendKey("file")
+	*s.buf = appendJSONString(*s.buf, src.File)
+	s.appendKey("line")
+	*s.buf = strconv.AppendInt(*s.buf, int64(src.Line), 10)
+	*s.buf = append(*s.buf, '}')
 }
@@ -0,0 +1,344 @@
+package unilogger_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/alecthomas/assert/v2"
+
+	"slog-test/unilogger"
+	logContext "slog-test/unilogger/context"
+)
+
+var stubTime = time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)
+
+func stubTimeFn(_ time.Time) time.Time {
+	return stubTime
+}
+
+func newStubRecord() slog.Record {
+	r := slog.NewRecord(stubTime, slog.LevelInfo, "stub msg", 0)
+	r.AddAttrs(
+		slog.String("string", "value"),
+		slog.Int("int", 42),
+		slog.Float64("float", 3.14),
+		slog.Bool("bool", true),
+		slog.Duration("duration", time.Second),
+	)
+
+	return r
+}
+
+func Test_SlogHandler_Groups(t *testing.T) {
+	t.Parallel()
+
+	buf := bytes.NewBuffer([]byte{})
+
+	var h slog.Handler = unilogger.NewHandler(buf, nil, stubTimeFn)
+	h = h.WithAttrs([]slog.Attr{unilogger.LoggerName("stub"), slog.Int("a", 1)})
+	h = h.WithGroup("g1").WithAttrs([]slog.Attr{slog.Int("b", 2)})
+	h = h.WithGroup("g2").WithGroup("empty")
+
+	r := slog.NewRecord(stubTime, slog.LevelInfo, "stub msg", 0)
+	r.AddAttrs(slog.Group("inner", slog.Int("c", 3)), slog.Group("skipped"))
+	assert.NoError(t, h.Handle(context.Background(), r))
+
+	r = slog.NewRecord(stubTime, slog.LevelInfo, "stub msg", 0)
+	assert.NoError(t, h.Handle(context.Background(), r))
+
+	want := []string{
+		`{"level":"info","logger":"stub","msg":"stub msg","a":1,"g1":{"b":2,"g2":{"empty":{"inner":{"c":3}}}},"time":"2006-01-02T15:04:05Z"}`,
+		`{"level":"info","logger":"stub","msg":"stub msg","a":1,"g1":{"b":2},"time":"2006-01-02T15:04:05Z"}`,
+	}
+
+	assert.Equal(t, strings.Join(want, "\n")+"\n", buf.String())
+}
+
+func Test_SlogHandler_ZeroAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("allocations are not stable under the race detector")
+	}
+
+	h := unilogger.NewHandler(io.Discard, nil, stubTimeFn).
+		WithAttrs([]slog.Attr{unilogger.LoggerName("stub"), slog.String("ctx", "value")}).
+		WithGroup("group")
+	r := newStubRecord()
+	ctx := context.Background()
+
+	allocs := testing.AllocsPerRun(100, func() {
+		_ = h.Handle(ctx, r)
+	})
+
+	assert.Equal(t, 0.0, allocs)
+}
+
+func Benchmark_SlogHandler(b *testing.B) {
+	handlers := []struct {
+		name string
+		h    slog.Handler
+	}{
+		{
+			name: "unilogger",
+			h:    unilogger.NewHandler(io.Discard, nil, stubTimeFn),
+		},
+		{
+			name: "legacy",
+			h:    newLegacyHandler(io.Discard, nil, stubTimeFn),
+		},
+	}
+
+	for _, tt := range handlers {
+		b.Run(tt.name, func(b *testing.B) {
+			h := tt.h.WithAttrs([]slog.Attr{unilogger.LoggerName("stub"), slog.String("ctx", "value")})
+			r := newStubRecord()
+			ctx := context.Background()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for range b.N {
+				_ = h.Handle(ctx, r)
+			}
+		})
+	}
+}
+
+// legacyHandler is the previous SlogHandler implementation, which renders
+// the record with slog.JSONHandler and reassembles it from a map.
+// It is kept to compare against in benchmarks.
+type legacyHandler struct {
+	slog.Handler
+
+	w io.Writer
+	b *bytes.Buffer
+	m *sync.Mutex
+
+	timeFn func(t time.Time) time.Time
+}
+
+func newLegacyHandler(out io.Writer, opts *slog.HandlerOptions, timeFn func(t time.Time) time.Time) *legacyHandler {
+	b := new(bytes.Buffer)
+
+	return &legacyHandler{
+		Handler: slog.NewJSONHandler(b, opts),
+		b:       b,
+		m:       &sync.Mutex{},
+		w:       out,
+		timeFn:  timeFn,
+	}
+}
+
+func (h *legacyHandler) Handle(ctx context.Context, r slog.Record) error {
+	h.m.Lock()
+
+	defer func() {
+		h.b.Reset()
+		h.m.Unlock()
+	}()
+
+	if err := h.Handler.Handle(ctx, r); err != nil {
+		return err
+	}
+
+	fields := map[string]any{}
+	if err := json.Unmarshal(h.b.Bytes(), &fields); err != nil {
+		return err
+	}
+
+	delete(fields, slog.LevelKey)
+	delete(fields, slog.MessageKey)
+	delete(fields, slog.TimeKey)
+
+	headLogFields := []string{fmt.Sprintf(`"level":"%s"`, unilogger.Level(r.Level).String())}
+
+	loggerName, ok := fields["logger"]
+	if ok {
+		headLogFields = append(headLogFields, fmt.Sprintf(`"logger":"%s"`, loggerName))
+
+		delete(fields, "logger")
+	}
+
+	headLogFields = append(headLogFields, fmt.Sprintf(`"msg":"%s"`, r.Message))
+	footLogFields := []string{fmt.Sprintf(`"time":"%s"`, h.timeFn(r.Time).Format(time.RFC3339))}
+
+	b, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+
+	var out []byte
+
+	out = append(out, '{')
+	out = append(out, []byte(strings.Join(headLogFields, ","))...)
+	out = append(out, ',')
+
+	if len(fields) > 0 {
+		out = append(out, b[1:len(b)-1]...)
+		out = append(out, ',')
+	}
+
+	out = append(out, []byte(strings.Join(footLogFields, ","))...)
+	out = append(out, '}')
+
+	_, err = h.w.Write(append(out, "\n"...))
+
+	return err
+}
+
+func (h *legacyHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.Handler = h.Handler.WithAttrs(attrs)
+
+	return &h2
+}
+
+func (h *legacyHandler) WithGroup(name string) slog.Handler {
+	h2 := *h
+	h2.Handler = h.Handler.WithGroup(name)
+
+	return &h2
+}
+
+func Fuzz_SlogHandler_ValidJSON(f *testing.F) {
+	f.Add("stub msg", "stub", "key", "value", 1.5)
+	f.Add(`quoted "msg"`, `back\slash`, "new\nline", "tab\tvalue", math.NaN())
+	f.Add("\x00\x1f\x7f", "\u2028\u2029", "\xff\xfe", "<html>&amp;", math.Inf(-1))
+
+	f.Fuzz(func(t *testing.T, msg, name, key, value string, number float64) {
+		buf := bytes.NewBuffer([]byte{})
+
+		logger := unilogger.NewLogger(unilogger.Options{
+			AddSource: true,
+			Level:     unilogger.LevelTrace.Level(),
+			Output:    buf,
+		})
+
+		ctx := logContext.SetCustomKeyContext(context.Background())
+		ctx = logContext.SetStackTraceContext(ctx, stubStack())
+
+		logger.Named(name).With(key, value).WithGroup(key).
+			Log(ctx, unilogger.LevelError.Level(), msg, key, number, slog.Group(value, name, msg))
+
+		for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+			if !json.Valid([]byte(line)) {
+				t.Fatalf("invalid json line: %s", line)
+			}
+
+			// user key may shadow the head and foot fields
+			if key == slog.MessageKey || key == "trace" {
+				continue
+			}
+
+			fields := map[string]any{}
+			assert.NoError(t, json.Unmarshal([]byte(line), &fields))
+
+			if utf8.ValidString(msg) {
+				assert.Equal(t, any(msg), fields["msg"])
+			}
+
+		}
+	})
+}
+
+func Test_SlogHandler_Layout(t *testing.T) {
+	t.Parallel()
+
+	type meta struct {
+		name    string
+		enabled bool
+	}
+
+	type args struct {
+		layout unilogger.Layout
+	}
+
+	type wants struct {
+		line string
+	}
+
+	tests := []struct {
+		meta  meta
+		args  args
+		wants wants
+	}{
+		{
+			meta: meta{
+				name:    "default layout",
+				enabled: true,
+			},
+			args: args{
+				layout: unilogger.DefaultLayout(),
+			},
+			wants: wants{
+				line: `{"level":"info","logger":"stub","msg":"stub msg","source":"unilogger/slog_test.go:339","a":1,"time":"2006-01-02T15:04:05Z"}`,
+			},
+		},
+		{
+			meta: meta{
+				name:    "renamed keys and time at the front",
+				enabled: true,
+			},
+			args: args{
+				layout: unilogger.Layout{
+					Head: []unilogger.LayoutField{
+						{Field: unilogger.FieldTime, Key: "ts"},
+						{Field: unilogger.FieldLevel, Key: "severity"},
+						{Field: unilogger.FieldMessage, Key: "message"},
+					},
+					Foot: []unilogger.LayoutField{
+						{Field: unilogger.FieldLogger, Key: "component"},
+						{Field: unilogger.FieldSource, Key: "caller"},
+					},
+				},
+			},
+			wants: wants{
+				line: `{"ts":"2006-01-02T15:04:05Z","severity":"info","message":"stub msg","a":1,"component":"stub","caller":"unilogger/slog_test.go:339"}`,
+			},
+		},
+		{
+			meta: meta{
+				name:    "attrs only",
+				enabled: true,
+			},
+			args: args{
+				layout: unilogger.Layout{
+					Foot: []unilogger.LayoutField{{Field: unilogger.FieldMessage}},
+				},
+			},
+			wants: wants{
+				line: `{"a":1,"msg":"stub msg"}`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		if !tt.meta.enabled {
+			continue
+		}
+
+		t.Run(tt.meta.name, func(t *testing.T) {
+			buf := bytes.NewBuffer([]byte{})
+
+			logger := unilogger.NewLogger(unilogger.Options{
+				AddSource: true,
+				Output:    buf,
+				Layout:    tt.args.layout,
+				TimeFunc:  stubTimeFn,
+			})
+
+			logger.Named("stub").With("a", 1).Logf(context.Background(), unilogger.LevelInfo, "stub msg")
+
+			assert.Equal(t, tt.wants.line+"\n", buf.String())
+		})
+	}
+}
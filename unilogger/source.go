@@ -0,0 +1,79 @@
+package unilogger
+
+import (
+	"log/slog"
+	"sync/atomic"
+)
+
+type SourceMode int
+
+const (
+	// SourceNever writes no source
+	SourceNever SourceMode = iota
+	// SourceAlways writes the source of every record
+	SourceAlways
+	// SourceAtLeast writes the source of the records at or above the policy level
+	SourceAtLeast
+	// SourceBelow writes the source of the records below the policy level
+	SourceBelow
+)
+
+// SourcePolicy decides which records are annotated with the source.
+type SourcePolicy struct {
+	Mode  SourceMode
+	Level Level
+}
+
+func (p SourcePolicy) enabled(level slog.Level) bool {
+	switch p.Mode {
+	case SourceAlways:
+		return true
+	case SourceAtLeast:
+		return level >= p.Level.Level()
+	case SourceBelow:
+		return level < p.Level.Level()
+	default:
+		return false
+	}
+}
+
+// sourceSwitch holds the source policy shared by a handler and its clones.
+type sourceSwitch struct {
+	policy atomic.Pointer[SourcePolicy]
+}
+
+func newSourceSwitch(p SourcePolicy) *sourceSwitch {
+	s := &sourceSwitch{}
+	s.set(p)
+
+	return s
+}
+
+func (s *sourceSwitch) set(p SourcePolicy) {
+	s.policy.Store(&p)
+}
+
+func (s *sourceSwitch) get() SourcePolicy {
+	return *s.policy.Load()
+}
+
+// sourcePolicyFor returns the policy of HandlerOptions.AddSource.
+func sourcePolicyFor(addSource bool) SourcePolicy {
+	if addSource {
+		return SourcePolicy{Mode: SourceAlways}
+	}
+
+	return SourcePolicy{Mode: SourceNever}
+}
+
+// SetSourcePolicy changes which records of the handler and of the handlers
+// derived from it are annotated with the source. HandlerOptions.AddSource
+// sets the initial policy.
+func (h *SlogHandler) SetSourcePolicy(p SourcePolicy) {
+	h.source.set(p)
+}
+
+// addSource reports whether the record gets the source.
+func (s *handleState) addSource() bool {
+	return s.hasRecord && s.record.PC != 0 && s.h.source.get().enabled(s.record.Level)
+}
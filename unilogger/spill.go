@@ -0,0 +1,167 @@
+package unilogger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const DefaultSpillMaxBytes = 64 << 20
+
+const (
+	spillPrefix = "spill-"
+	spillSuffix = ".jsonl"
+)
+
+// spillSegment is a file of the spill queue, replayed from offset.
+type spillSegment struct {
+	path   string
+	size   int64
+	offset int64
+}
+
+// spillQueue keeps the records on disk in segments, the oldest first.
+// The last segment is appended to until the replay takes it. It is
+// guarded by the lock of its writer.
+type spillQueue struct {
+	dir string
+	max int64
+
+	// bytes not replayed yet
+	size int64
+	segs []spillSegment
+	// append handle of the last segment, nil if it is taken by the replay
+	file *os.File
+	seq  uint64
+}
+
+// openSpillQueue picks up the segments left in dir by the previous runs.
+func openSpillQueue(dir string, maxBytes int64) (*spillQueue, error) {
+	if maxBytes <= 0 {
+		maxBytes = DefaultSpillMaxBytes
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("spill queue: %w", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("spill queue: %w", err)
+	}
+
+	q := &spillQueue{dir: dir, max: maxBytes}
+
+	for _, e := range entries {
+		seq, ok := spillSeq(e.Name())
+		if !ok || e.IsDir() {
+			continue
+		}
+
+		info, err := e.Info()
+		if err != nil {
+			return nil, fmt.Errorf("spill queue: %w", err)
+		}
+
+		q.segs = append(q.segs, spillSegment{path: filepath.Join(dir, e.Name()), size: info.Size()})
+		q.size += info.Size()
+		q.seq = max(q.seq, seq)
+	}
+
+	// the names sort by the sequence
+	slices.SortFunc(q.segs, func(a, b spillSegment) int {
+		return strings.Compare(a.path, b.path)
+	})
+
+	return q, nil
+}
+
+func spillSeq(name string) (uint64, bool) {
+	if !strings.HasPrefix(name, spillPrefix) || !strings.HasSuffix(name, spillSuffix) {
+		return 0, false
+	}
+
+	seq, err := strconv.ParseUint(name[len(spillPrefix):len(name)-len(spillSuffix)], 10, 64)
+
+	return seq, err == nil
+}
+
+func (q *spillQueue) empty() bool {
+	return len(q.segs) == 0
+}
+
+// append adds the records to the last segment, or to a new one.
+func (q *spillQueue) append(p []byte) error {
+	if q.size+int64(len(p)) > q.max {
+		return fmt.Errorf("spill queue is full: %d bytes", q.size)
+	}
+
+	if q.file == nil {
+		q.seq++
+
+		path := filepath.Join(q.dir, fmt.Sprintf("%s%020d%s", spillPrefix, q.seq, spillSuffix))
+
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return fmt.Errorf("spill queue: %w", err)
+		}
+
+		q.file = f
+		q.segs = append(q.segs, spillSegment{path: path})
+	}
+
+	n, err := q.file.Write(p)
+
+	q.segs[len(q.segs)-1].size += int64(n)
+	q.size += int64(n)
+
+	if err != nil {
+		return fmt.Errorf("spill queue: %w", err)
+	}
+
+	return nil
+}
+
+// head returns the oldest segment, closing it for appends.
+func (q *spillQueue) head() (spillSegment, bool) {
+	if len(q.segs) == 0 {
+		return spillSegment{}, false
+	}
+
+	if q.file != nil && len(q.segs) == 1 {
+		q.file.Close()
+		q.file = nil
+	}
+
+	return q.segs[0], true
+}
+
+// advance marks n bytes of the oldest segment as replayed.
+func (q *spillQueue) advance(n int64) {
+	q.segs[0].offset += n
+	q.size -= n
+}
+
+// pop removes the replayed oldest segment.
+func (q *spillQueue) pop() error {
+	seg := q.segs[0]
+	q.segs = q.segs[1:]
+	q.size -= seg.size - seg.offset
+
+	return os.Remove(seg.path)
+}
+
+// close closes the last segment, the segments stay for the next run.
+func (q *spillQueue) close() error {
+	if q.file == nil {
+		return nil
+	}
+
+	err := q.file.Close()
+	q.file = nil
+
+	return err
+}
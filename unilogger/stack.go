@@ -0,0 +1,114 @@
+package unilogger
+
+import (
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// maximum number of callers captured for a stack trace
+const maxStackDepth = 64
+
+// prefix of the functions of this package, to trim them from the traces
+var internalPrefix = reflect.TypeOf(Logger{}).PkgPath() + "."
+
+// Frame is a single call of the stack trace.
+type Frame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+// StackOptions control which frames of the stack traces are written.
+type StackOptions struct {
+	// TrimRuntime drops the frames of the go runtime
+	TrimRuntime bool
+	// TrimInternal drops the frames of the logger itself
+	TrimInternal bool
+	// MaxDepth caps the number of frames, 0 means no limit
+	MaxDepth int
+}
+
+// frames resolves the callers and applies the options.
+func (o StackOptions) frames(pcs []uintptr) []Frame {
+	frames := make([]Frame, 0, len(pcs))
+
+	fs := runtime.CallersFrames(pcs)
+
+	for {
+		f, more := fs.Next()
+
+		switch {
+		case o.MaxDepth > 0 && len(frames) >= o.MaxDepth:
+			return frames
+		case o.TrimRuntime && strings.HasPrefix(f.Function, "runtime."):
+		case o.TrimInternal && strings.HasPrefix(f.Function, internalPrefix):
+		default:
+			frames = append(frames, Frame{
+				Function: f.Function,
+				File:     f.File,
+				Line:     f.Line,
+			})
+		}
+
+		if !more {
+			return frames
+		}
+	}
+}
+
+// getStack captures the callers of the current goroutine, starting with getStack.
+func getStack() []uintptr {
+	pcs := make([]uintptr, maxStackDepth)
+	n := runtime.Callers(1, pcs)
+
+	return pcs[:n]
+}
+
+func (s *handleState) appendStack(pcs []uintptr) {
+	s.appendFrames(s.h.stackOpts.frames(pcs))
+}
+
+// appendFrames writes the frames as an array of objects in JSON and
+// as "function\n\tfile:line" lines in the key=value formats.
+func (s *handleState) appendFrames(frames []Frame) {
+	if s.h.format != FormatJSON {
+		var b strings.Builder
+
+		for i, f := range frames {
+			if i > 0 {
+				b.WriteByte('\n')
+			}
+
+			b.WriteString(f.Function)
+			b.WriteString("\n\t")
+			b.WriteString(f.File)
+			b.WriteByte(':')
+			b.WriteString(strconv.Itoa(f.Line))
+		}
+
+		*s.buf = appendTextString(*s.buf, b.String())
+
+		return
+	}
+
+	*s.buf = append(*s.buf, '[')
+
+	for i, f := range frames {
+		if i > 0 {
+			*s.buf = append(*s.buf, ',')
+		}
+
+		*s.buf = append(*s.buf, '{')
+		s.appendKey("function")
+		*s.buf = appendJSONString(*s.buf, f.Function)
+		s.appendKey("file")
+		*s.buf = appendJSONString(*s.buf, f.File)
+		s.appendKey("line")
+		*s.buf = strconv.AppendInt(*s.buf, int64(f.Line), 10)
+		*s.buf = append(*s.buf, '}')
+	}
+
+	*s.buf = append(*s.buf, ']')
+}
@@ -0,0 +1,183 @@
+package unilogger_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+
+	"slog-test/unilogger"
+)
+
+func stubStack() []uintptr {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(1, pcs)
+
+	return pcs[:n]
+}
+
+type stubFrame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+func Test_Stack(t *testing.T) {
+	t.Parallel()
+
+	type meta struct {
+		name    string
+		enabled bool
+	}
+
+	type args struct {
+		stack unilogger.StackOptions
+	}
+
+	type wants struct {
+		firstFunction  string
+		maxDepth       int
+		shouldContains []string
+		shouldNotHave  []string
+	}
+
+	tests := []struct {
+		meta  meta
+		args  args
+		wants wants
+	}{
+		{
+			meta: meta{
+				name:    "full trace starts in the logger",
+				enabled: true,
+			},
+			args: args{},
+			wants: wants{
+				firstFunction:  "slog-test/unilogger.getStack",
+				shouldContains: []string{"slog-test/unilogger.(*Logger).Trace", "runtime.goexit"},
+			},
+		},
+		{
+			meta: meta{
+				name:    "trimmed trace starts at the caller",
+				enabled: true,
+			},
+			args: args{
+				stack: unilogger.StackOptions{
+					TrimRuntime:  true,
+					TrimInternal: true,
+				},
+			},
+			wants: wants{
+				firstFunction: "slog-test/unilogger_test.Test_Stack",
+				shouldNotHave: []string{"slog-test/unilogger.", "runtime."},
+			},
+		},
+		{
+			meta: meta{
+				name:    "depth is capped",
+				enabled: true,
+			},
+			args: args{
+				stack: unilogger.StackOptions{
+					TrimInternal: true,
+					MaxDepth:     1,
+				},
+			},
+			wants: wants{
+				firstFunction: "slog-test/unilogger_test.Test_Stack",
+				maxDepth:      1,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		if !tt.meta.enabled {
+			continue
+		}
+
+		t.Run(tt.meta.name, func(t *testing.T) {
+			buf := bytes.NewBuffer([]byte{})
+
+			logger := unilogger.NewLogger(unilogger.Options{
+				Level:  unilogger.LevelTrace.Level(),
+				Output: buf,
+				Stack:  tt.args.stack,
+			})
+
+			logger.Trace("stub msg")
+
+			var record struct {
+				Trace []stubFrame `json:"trace"`
+			}
+
+			assert.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+			assert.NotZero(t, len(record.Trace))
+			assert.True(t, strings.HasPrefix(record.Trace[0].Function, tt.wants.firstFunction))
+			assert.NotZero(t, record.Trace[0].File)
+			assert.NotZero(t, record.Trace[0].Line)
+
+			if tt.wants.maxDepth > 0 {
+				assert.Equal(t, tt.wants.maxDepth, len(record.Trace))
+			}
+
+			functions := make([]string, 0, len(record.Trace))
+			for _, f := range record.Trace {
+				functions = append(functions, f.Function)
+			}
+
+			for _, v := range tt.wants.shouldContains {
+				assert.Contains(t, strings.Join(functions, "\n"), v)
+			}
+
+			for _, v := range tt.wants.shouldNotHave {
+				for _, fn := range functions {
+					assert.False(t, strings.HasPrefix(fn, v), fn)
+				}
+			}
+		})
+	}
+}
+
+func Test_Stack_TextFormats(t *testing.T) {
+	t.Parallel()
+
+	stack := unilogger.StackOptions{
+		TrimRuntime:  true,
+		TrimInternal: true,
+		MaxDepth:     1,
+	}
+
+	buf := bytes.NewBuffer([]byte{})
+
+	unilogger.NewLogger(unilogger.Options{
+		Format:   unilogger.FormatConsole,
+		Level:    unilogger.LevelTrace.Level(),
+		Output:   buf,
+		Stack:    stack,
+		TimeFunc: stubTimeFn,
+	}).Trace("stub msg")
+
+	lines := strings.Split(buf.String(), "\n")
+	assert.Equal(t, 4, len(lines))
+	assert.Equal(t, "2006-01-02T15:04:05Z TRACE stub msg", lines[0])
+	assert.True(t, strings.HasPrefix(lines[1], "\tslog-test/unilogger_test.Test_Stack_TextFormats"))
+	assert.True(t, strings.HasPrefix(lines[2], "\t\t"))
+	assert.Contains(t, lines[2], "unilogger/stack_test.go:")
+
+	buf.Reset()
+
+	unilogger.NewLogger(unilogger.Options{
+		Format:   unilogger.FormatLogfmt,
+		Level:    unilogger.LevelTrace.Level(),
+		Output:   buf,
+		Stack:    stack,
+		TimeFunc: stubTimeFn,
+	}).Trace("stub msg")
+
+	assert.Equal(t, 1, strings.Count(buf.String(), "\n"))
+	assert.Contains(t, buf.String(), `trace="slog-test/unilogger_test.Test_Stack_TextFormats\n\t`)
+}
@@ -0,0 +1,482 @@
+package unilogger
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Facility is the syslog facility of the records.
+type Facility int
+
+const (
+	FacilityKern Facility = iota
+	FacilityUser
+	FacilityMail
+	FacilityDaemon
+	FacilityAuth
+	FacilitySyslog
+	FacilityLPR
+	FacilityNews
+	FacilityUUCP
+	FacilityCron
+	FacilityAuthPriv
+	FacilityFTP
+)
+
+const (
+	FacilityLocal0 Facility = iota + 16
+	FacilityLocal1
+	FacilityLocal2
+	FacilityLocal3
+	FacilityLocal4
+	FacilityLocal5
+	FacilityLocal6
+	FacilityLocal7
+)
+
+// DefaultSDID is the STRUCTURED-DATA id of the attributes. 32473 is the
+// enterprise number reserved for documentation, use your own.
+const DefaultSDID = "attrs@32473"
+
+// SyslogOptions are the header fields of the RFC 5424 messages.
+type SyslogOptions struct {
+	// Facility, FacilityUser if zero as the kernel one is not for processes
+	Facility Facility
+	// AppName is the base name of the executable if empty
+	AppName string
+	// Hostname is os.Hostname if empty
+	Hostname string
+	// ProcID is the process id if empty
+	ProcID string
+	// SDID is the id of the element with the attributes, DefaultSDID if empty
+	SDID string
+}
+
+// syslogHeader is SyslogOptions with the defaults applied.
+type syslogHeader struct {
+	facility Facility
+	hostname string
+	appName  string
+	procID   string
+	sdID     string
+}
+
+func newSyslogHeader(opts SyslogOptions) *syslogHeader {
+	if opts.Facility == FacilityKern {
+		opts.Facility = FacilityUser
+	}
+
+	if opts.AppName == "" && len(os.Args) > 0 {
+		opts.AppName = filepath.Base(os.Args[0])
+	}
+
+	if opts.Hostname == "" {
+		opts.Hostname, _ = os.Hostname()
+	}
+
+	if opts.ProcID == "" {
+		opts.ProcID = strconv.Itoa(os.Getpid())
+	}
+
+	if opts.SDID == "" {
+		opts.SDID = DefaultSDID
+	}
+
+	return &syslogHeader{
+		facility: opts.Facility,
+		hostname: syslogName(opts.Hostname, 255),
+		appName:  syslogName(opts.AppName, 48),
+		procID:   syslogName(opts.ProcID, 128),
+		sdID:     sdName(opts.SDID),
+	}
+}
+
+// NewSyslogHandler returns a handler which writes RFC 5424 messages, one per line:
+//
+//	<14>1 2006-01-02T15:04:05.000000Z host app 42 db [attrs@32473 group.key="value"] stub msg
+//
+// The logger name is the MSGID, the attributes are flattened into the
+// STRUCTURED-DATA element. New lines are escaped, so the records can be
+// split by them, see SyslogWriter.
+func NewSyslogHandler(out io.Writer, opts *slog.HandlerOptions, timeFn func(t time.Time) time.Time, sysOpts SyslogOptions) *SlogHandler {
+	return NewHandler(out, opts, timeFn).WithSyslog(sysOpts)
+}
+
+// WithSyslog returns a handler which writes RFC 5424 messages with the header options.
+func (h *SlogHandler) WithSyslog(opts SyslogOptions) *SlogHandler {
+	h2 := h.clone()
+	h2.format = FormatSyslog
+	h2.color = false
+	h2.syslog = newSyslogHeader(opts)
+
+	return h2
+}
+
+// SyslogSeverity maps the level to the syslog severity. Trace and debug
+// are debug, the levels between info and warn are notice, panic and fatal
+// are critical.
+func SyslogSeverity(l Level) int {
+	switch {
+	case l >= LevelPanic:
+		return 2
+	case l >= LevelError:
+		return 3
+	case l >= LevelWarn:
+		return 4
+	case l > LevelInfo:
+		return 5
+	case l == LevelInfo:
+		return 6
+	default:
+		return 7
+	}
+}
+
+// appendSyslogRecord writes the record as an RFC 5424 message.
+func (s *handleState) appendSyslogRecord() {
+	r := &s.record
+	hdr := s.h.syslog
+
+	*s.buf = append(*s.buf, '<')
+	*s.buf = strconv.AppendInt(*s.buf, int64(int(hdr.facility)*8+SyslogSeverity(Level(r.Level))), 10)
+	*s.buf = append(*s.buf, ">1 "...)
+	*s.buf = s.h.timeFn(r.Time).AppendFormat(*s.buf, "2006-01-02T15:04:05.000000Z07:00")
+
+	for _, field := range [...]string{hdr.hostname, hdr.appName, hdr.procID, syslogName(s.h.name, 32)} {
+		*s.buf = append(*s.buf, ' ')
+		*s.buf = append(*s.buf, field...)
+	}
+
+	*s.buf = append(*s.buf, ' ')
+	pos := len(*s.buf)
+
+	*s.buf = append(*s.buf, '[')
+	*s.buf = append(*s.buf, hdr.sdID...)
+	params := len(*s.buf)
+
+	s.flatAttrs(s.appendSDParam)
+
+	if s.addSource() {
+		s.appendSourceSDParam(r.PC)
+	}
+
+	if len(*s.buf) == params {
+		*s.buf = append((*s.buf)[:pos], '-')
+	} else {
+		*s.buf = append(*s.buf, ']')
+	}
+
+	if r.Message != "" {
+		*s.buf = append(*s.buf, ' ')
+		*s.buf = appendSyslogMsg(*s.buf, r.Message)
+	}
+
+	*s.buf = append(*s.buf, '\n')
+}
+
+// appendSDParam writes the attribute as an SD-PARAM with the group names
+// joined into the param name.
+func (s *handleState) appendSDParam(groups []string, a slog.Attr) {
+	*s.buf = append(*s.buf, ' ')
+	start := len(*s.buf)
+
+	for _, g := range groups {
+		*s.buf = appendSDName(*s.buf, g, start)
+		*s.buf = appendSDName(*s.buf, ".", start)
+	}
+
+	*s.buf = appendSDName(*s.buf, a.Key, start)
+	*s.buf = append(*s.buf, `="`...)
+	*s.buf = appendSDValue(*s.buf, a.Value)
+	*s.buf = append(*s.buf, '"')
+}
+
+func (s *handleState) appendSourceSDParam(pc uintptr) {
+	a := s.sourceAttr(pc)
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+
+	*s.buf = append(*s.buf, ' ')
+	*s.buf = append(*s.buf, slog.SourceKey...)
+	*s.buf = append(*s.buf, `="`...)
+
+	if src, ok := a.Value.Any().(*slog.Source); ok {
+		*s.buf = appendSDString(*s.buf, src.File+":"+strconv.Itoa(src.Line))
+	} else {
+		*s.buf = appendSDValue(*s.buf, a.Value)
+	}
+
+	*s.buf = append(*s.buf, '"')
+}
+
+// appendSDValue writes the value of an SD-PARAM, without the quotes.
+func appendSDValue(buf []byte, v slog.Value) []byte {
+	text := newBuffer()
+	defer text.Free()
+
+	*text = appendPlainValue(*text, v)
+
+	return appendSDString(buf, []byte(*text))
+}
+
+// appendSDString escapes '"', '\' and ']' as RFC 5424 requires,
+// and the new lines.
+func appendSDString[S string | []byte](buf []byte, s S) []byte {
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '"', '\\', ']':
+			buf = append(buf, '\\', c)
+		case '\n':
+			buf = append(buf, `\n`...)
+		case '\r':
+			buf = append(buf, `\r`...)
+		default:
+			buf = append(buf, c)
+		}
+	}
+
+	return buf
+}
+
+func appendSyslogMsg(buf []byte, s string) []byte {
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\n':
+			buf = append(buf, `\n`...)
+		case '\r':
+			buf = append(buf, `\r`...)
+		default:
+			buf = append(buf, c)
+		}
+	}
+
+	return buf
+}
+
+// appendSDName writes s as a part of the SD-NAME starting at start:
+// printable ASCII without '=', ' ', ']' and '"', 32 characters at most.
+func appendSDName(buf []byte, s string, start int) []byte {
+	for i := 0; i < len(s) && len(buf)-start < 32; i++ {
+		c := s[i]
+		if c <= ' ' || c >= 0x7f || c == '=' || c == ']' || c == '"' {
+			c = '_'
+		}
+
+		buf = append(buf, c)
+	}
+
+	return buf
+}
+
+func sdName(s string) string {
+	return string(appendSDName(nil, s, 0))
+}
+
+// syslogName returns s as a header field: printable ASCII,
+// limit characters at most, "-" if empty.
+func syslogName(s string, limit int) string {
+	if s == "" {
+		return "-"
+	}
+
+	b := []byte(s)
+	if len(b) > limit {
+		b = b[:limit]
+	}
+
+	for i, c := range b {
+		if c <= ' ' || c >= 0x7f {
+			b[i] = '_'
+		}
+	}
+
+	return string(b)
+}
+
+// ErrBackoff is returned by the network writers between the failed
+// attempts to connect.
+var ErrBackoff = errors.New("waiting to reconnect")
+
+// SyslogWriterOptions configure the connection of SyslogWriter.
+type SyslogWriterOptions struct {
+	// DialTimeout and WriteTimeout are 5 seconds if zero
+	DialTimeout  time.Duration
+	WriteTimeout time.Duration
+	// MinBackoff and MaxBackoff bound the delays between the reconnects,
+	// DefaultMinBackoff and DefaultMaxBackoff if zero
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+var _ io.WriteCloser = (*SyslogWriter)(nil)
+
+// SyslogWriter sends the lines written by the syslog handler to a syslog
+// server over "udp", "tcp", "unix" or "unixgram". Datagrams carry a message
+// each, streams frame them by octet counting (RFC 6587). A broken connection
+// is dialed again at once, then with an exponential backoff; the records
+// written meanwhile fail fast with ErrBackoff instead of blocking.
+type SyslogWriter struct {
+	network string
+	addr    string
+	opts    SyslogWriterOptions
+	stream  bool
+
+	mu      sync.Mutex
+	conn    net.Conn
+	backoff backoff
+	closed  bool
+}
+
+// DialSyslog connects to the syslog server at addr.
+func DialSyslog(network, addr string, opts SyslogWriterOptions) (*SyslogWriter, error) {
+	if opts.DialTimeout <= 0 {
+		opts.DialTimeout = 5 * time.Second
+	}
+
+	if opts.WriteTimeout <= 0 {
+		opts.WriteTimeout = 5 * time.Second
+	}
+
+	w := &SyslogWriter{
+		network: network,
+		addr:    addr,
+		opts:    opts,
+		backoff: newBackoff(opts.MinBackoff, opts.MaxBackoff),
+	}
+
+	switch network {
+	case "tcp", "tcp4", "tcp6", "unix":
+		w.stream = true
+	case "udp", "udp4", "udp6", "unixgram":
+	default:
+		return nil, fmt.Errorf("syslog: unsupported network %q", network)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.dial(); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// Write sends every line of p as a message.
+func (w *SyslogWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, net.ErrClosed
+	}
+
+	for n := 0; n < len(p); {
+		line, _, _ := bytes.Cut(p[n:], []byte{'\n'})
+
+		if len(line) > 0 {
+			if err := w.send(line); err != nil {
+				return n, err
+			}
+		}
+
+		n += len(line) + 1
+	}
+
+	return len(p), nil
+}
+
+func (w *SyslogWriter) send(msg []byte) error {
+	if w.conn != nil {
+		if err := w.writeMsg(msg); err == nil {
+			return nil
+		}
+
+		// the server may have restarted, dial again at once
+		w.conn.Close()
+		w.conn = nil
+		w.backoff.reset()
+	}
+
+	if err := w.dial(); err != nil {
+		return err
+	}
+
+	if err := w.writeMsg(msg); err != nil {
+		w.conn.Close()
+		w.conn = nil
+		w.backoff.fail(time.Now())
+
+		return fmt.Errorf("syslog: %w", err)
+	}
+
+	return nil
+}
+
+func (w *SyslogWriter) dial() error {
+	now := time.Now()
+	if !w.backoff.ready(now) {
+		return fmt.Errorf("syslog: %w", ErrBackoff)
+	}
+
+	conn, err := net.DialTimeout(w.network, w.addr, w.opts.DialTimeout)
+	if err != nil {
+		w.backoff.fail(now)
+
+		return fmt.Errorf("syslog: %w", err)
+	}
+
+	w.backoff.reset()
+	w.conn = conn
+
+	return nil
+}
+
+func (w *SyslogWriter) writeMsg(msg []byte) error {
+	if err := w.conn.SetWriteDeadline(time.Now().Add(w.opts.WriteTimeout)); err != nil {
+		return err
+	}
+
+	if !w.stream {
+		_, err := w.conn.Write(msg)
+
+		return err
+	}
+
+	frame := make([]byte, 0, len(msg)+8)
+	frame = strconv.AppendInt(frame, int64(len(msg)), 10)
+	frame = append(frame, ' ')
+	frame = append(frame, msg...)
+
+	_, err := w.conn.Write(frame)
+
+	return err
+}
+
+// Close closes the connection.
+func (w *SyslogWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return net.ErrClosed
+	}
+
+	w.closed = true
+
+	if w.conn == nil {
+		return nil
+	}
+
+	return w.conn.Close()
+}
@@ -0,0 +1,365 @@
+package unilogger_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/assert/v2"
+
+	"slog-test/unilogger"
+)
+
+var stubSyslogOptions = unilogger.SyslogOptions{
+	Hostname: "host",
+	AppName:  "app",
+	ProcID:   "42",
+}
+
+func Test_SyslogHandler(t *testing.T) {
+	t.Parallel()
+
+	type meta struct {
+		name    string
+		enabled bool
+	}
+
+	type fields struct {
+		opts  unilogger.SyslogOptions
+		logfn func(logger *unilogger.Logger)
+	}
+
+	type wants struct {
+		output string
+	}
+
+	tests := []struct {
+		meta   meta
+		fields fields
+		wants  wants
+	}{
+		{
+			meta: meta{
+				name:    "no attrs",
+				enabled: true,
+			},
+			fields: fields{
+				opts: stubSyslogOptions,
+				logfn: func(logger *unilogger.Logger) {
+					logger.Info("stub msg")
+				},
+			},
+			wants: wants{
+				output: `<14>1 2006-01-02T15:04:05.000000Z host app 42 - - stub msg` + "\n",
+			},
+		},
+		{
+			meta: meta{
+				name:    "severities",
+				enabled: true,
+			},
+			fields: fields{
+				opts: unilogger.SyslogOptions{Facility: unilogger.FacilityLocal0, Hostname: "host", AppName: "app", ProcID: "42"},
+				logfn: func(logger *unilogger.Logger) {
+					logger.Trace("trace")
+					logger.Warn("warn")
+					logger.Log(context.Background(), slog.Level(unilogger.LevelInfo+2), "notice")
+					logger.Log(context.Background(), slog.Level(unilogger.LevelFatal), "fatal")
+				},
+			},
+			wants: wants{
+				output: "" +
+					`<135>1 2006-01-02T15:04:05.000000Z host app 42 - - trace` + "\n" +
+					`<132>1 2006-01-02T15:04:05.000000Z host app 42 - - warn` + "\n" +
+					`<133>1 2006-01-02T15:04:05.000000Z host app 42 - - notice` + "\n" +
+					`<130>1 2006-01-02T15:04:05.000000Z host app 42 - - fatal` + "\n",
+			},
+		},
+		{
+			meta: meta{
+				name:    "structured data",
+				enabled: true,
+			},
+			fields: fields{
+				opts: stubSyslogOptions,
+				logfn: func(logger *unilogger.Logger) {
+					logger.Named("db").With("a", 1).WithGroup("query").
+						Error("two\nlines", "sql", `select "x"]`, slog.Group("inner", "ok", true), unilogger.Err(errors.New("stub error")))
+				},
+			},
+			wants: wants{
+				output: `<11>1 2006-01-02T15:04:05.000000Z host app 42 db [attrs@32473 a="1" query.sql="select \"x\"\]" query.inner.ok="true" query.error="stub error"] two\nlines` + "\n",
+			},
+		},
+		{
+			meta: meta{
+				name:    "sanitized names",
+				enabled: true,
+			},
+			fields: fields{
+				opts: unilogger.SyslogOptions{Hostname: "my host", AppName: "app", ProcID: "42", SDID: "x@1"},
+				logfn: func(logger *unilogger.Logger) {
+					logger.Info("stub msg", "key with=spaces", "v", strings.Repeat("k", 40), "long")
+				},
+			},
+			wants: wants{
+				output: `<14>1 2006-01-02T15:04:05.000000Z my_host app 42 - [x@1 key_with_spaces="v" ` + strings.Repeat("k", 32) + `="long"] stub msg` + "\n",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		if !tt.meta.enabled {
+			continue
+		}
+
+		t.Run(tt.meta.name, func(t *testing.T) {
+			buf := bytes.NewBuffer([]byte{})
+
+			logger := unilogger.NewLogger(unilogger.Options{
+				Format:   unilogger.FormatSyslog,
+				Syslog:   tt.fields.opts,
+				Level:    slog.Level(unilogger.LevelTrace),
+				Output:   buf,
+				TimeFunc: stubTimeFn,
+			})
+
+			tt.fields.logfn(logger)
+
+			assert.Equal(t, tt.wants.output, buf.String())
+		})
+	}
+}
+
+func Test_SyslogSeverity(t *testing.T) {
+	t.Parallel()
+
+	levels := []unilogger.Level{
+		unilogger.LevelTrace, unilogger.LevelDebug, unilogger.LevelInfo, unilogger.LevelInfo + 1,
+		unilogger.LevelWarn, unilogger.LevelError, unilogger.LevelPanic, unilogger.LevelFatal,
+	}
+
+	var severities []int
+	for _, l := range levels {
+		severities = append(severities, unilogger.SyslogSeverity(l))
+	}
+
+	assert.Equal(t, []int{7, 7, 6, 5, 4, 3, 2, 2}, severities)
+}
+
+// shortTempDir returns a directory for the unix sockets, whose paths are
+// limited to about 100 bytes.
+func shortTempDir(t *testing.T) string {
+	t.Helper()
+
+	dir, err := os.MkdirTemp("", "ul")
+	assert.NoError(t, err)
+
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+
+	return dir
+}
+
+// syslogListener receives the messages of a stub syslog server.
+type syslogListener struct {
+	addr string
+	msgs chan string
+	stop func()
+}
+
+func listenSyslog(t *testing.T, network, addr string) *syslogListener {
+	t.Helper()
+
+	l := &syslogListener{msgs: make(chan string, 16)}
+
+	switch network {
+	case "udp", "unixgram":
+		conn, err := net.ListenPacket(network, addr)
+		assert.NoError(t, err)
+
+		l.addr = conn.LocalAddr().String()
+		l.stop = func() { conn.Close() }
+
+		go func() {
+			buf := make([]byte, 64<<10)
+
+			for {
+				n, _, err := conn.ReadFrom(buf)
+				if err != nil {
+					return
+				}
+
+				l.msgs <- string(buf[:n])
+			}
+		}()
+	default:
+		ln, err := net.Listen(network, addr)
+		assert.NoError(t, err)
+
+		var (
+			mu    sync.Mutex
+			conns []net.Conn
+		)
+
+		l.addr = ln.Addr().String()
+		l.stop = func() {
+			ln.Close()
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			for _, conn := range conns {
+				conn.Close()
+			}
+		}
+
+		go func() {
+			for {
+				conn, err := ln.Accept()
+				if err != nil {
+					return
+				}
+
+				mu.Lock()
+				conns = append(conns, conn)
+				mu.Unlock()
+
+				go readOctetCounted(conn, l.msgs)
+			}
+		}()
+	}
+
+	t.Cleanup(l.stop)
+
+	return l
+}
+
+// readOctetCounted reads the "LEN MSG" frames of RFC 6587.
+func readOctetCounted(conn net.Conn, msgs chan<- string) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+
+	for {
+		size, err := r.ReadString(' ')
+		if err != nil {
+			return
+		}
+
+		n, err := strconv.Atoi(strings.TrimSpace(size))
+		if err != nil {
+			return
+		}
+
+		msg := make([]byte, n)
+		if _, err := io.ReadFull(r, msg); err != nil {
+			return
+		}
+
+		msgs <- string(msg)
+	}
+}
+
+func (l *syslogListener) next(t *testing.T) string {
+	t.Helper()
+
+	select {
+	case msg := <-l.msgs:
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("no syslog message")
+
+		return ""
+	}
+}
+
+func Test_SyslogWriter(t *testing.T) {
+	t.Parallel()
+
+	for _, network := range []string{"udp", "tcp", "unixgram", "unix"} {
+		t.Run(network, func(t *testing.T) {
+			t.Parallel()
+
+			addr := "127.0.0.1:0"
+			if strings.HasPrefix(network, "unix") {
+				addr = filepath.Join(shortTempDir(t), "log.sock")
+			}
+
+			l := listenSyslog(t, network, addr)
+
+			w, err := unilogger.DialSyslog(network, l.addr, unilogger.SyslogWriterOptions{})
+			assert.NoError(t, err)
+
+			logger := unilogger.NewLogger(unilogger.Options{
+				Format:   unilogger.FormatSyslog,
+				Syslog:   stubSyslogOptions,
+				Output:   w,
+				TimeFunc: stubTimeFn,
+			})
+
+			logger.Info("first", "n", 1)
+			logger.Named("db").Error("second\nline")
+
+			assert.Equal(t, `<14>1 2006-01-02T15:04:05.000000Z host app 42 - [attrs@32473 n="1"] first`, l.next(t))
+			assert.Equal(t, `<11>1 2006-01-02T15:04:05.000000Z host app 42 db - second\nline`, l.next(t))
+			assert.NoError(t, w.Close())
+		})
+	}
+}
+
+func Test_SyslogWriter_Reconnect(t *testing.T) {
+	t.Parallel()
+
+	l := listenSyslog(t, "tcp", "127.0.0.1:0")
+
+	w, err := unilogger.DialSyslog("tcp", l.addr, unilogger.SyslogWriterOptions{MinBackoff: 50 * time.Millisecond})
+	assert.NoError(t, err)
+
+	defer w.Close()
+
+	_, err = io.WriteString(w, "one\n")
+	assert.NoError(t, err)
+	assert.Equal(t, "one", l.next(t))
+
+	// the server goes away, the writes fail and then back off
+	l.stop()
+
+	for i := 0; err == nil; i++ {
+		assert.True(t, i < 100)
+		_, err = io.WriteString(w, "lost\n")
+	}
+
+	_, err = io.WriteString(w, "lost\n")
+	assert.True(t, errors.Is(err, unilogger.ErrBackoff), "%v", err)
+
+	// the server is back on the same address
+	l = listenSyslog(t, "tcp", l.addr)
+
+	deadline := time.Now().Add(5 * time.Second)
+
+	for {
+		_, err = fmt.Fprint(w, "two\n")
+		if err == nil || time.Now().After(deadline) {
+			break
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	assert.NoError(t, err)
+	assert.Equal(t, "two", l.next(t))
+}
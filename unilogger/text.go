@@ -0,0 +1,151 @@
+package unilogger
+
+import (
+	"encoding"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// appendTextValue writes v for the key=value formats.
+func appendTextValue(buf []byte, v slog.Value) []byte {
+	switch v.Kind() {
+	case slog.KindString:
+		return appendTextString(buf, v.String())
+	case slog.KindInt64:
+		return strconv.AppendInt(buf, v.Int64(), 10)
+	case slog.KindUint64:
+		return strconv.AppendUint(buf, v.Uint64(), 10)
+	case slog.KindFloat64:
+		return strconv.AppendFloat(buf, v.Float64(), 'g', -1, 64)
+	case slog.KindBool:
+		return strconv.AppendBool(buf, v.Bool())
+	case slog.KindDuration:
+		return append(buf, v.Duration().String()...)
+	case slog.KindTime:
+		return v.Time().AppendFormat(buf, time.RFC3339Nano)
+	default:
+		switch a := v.Any().(type) {
+		case FormattedValue:
+			return a.appendText(buf)
+		case encoding.TextMarshaler:
+			data, err := a.MarshalText()
+			if err != nil {
+				return appendTextString(buf, "!ERROR:"+err.Error())
+			}
+
+			return appendTextString(buf, string(data))
+		case []byte:
+			return strconv.AppendQuote(buf, string(a))
+		default:
+			return appendTextString(buf, fmt.Sprintf("%+v", a))
+		}
+	}
+}
+
+func appendTextString(buf []byte, s string) []byte {
+	if needsQuoting(s) {
+		return strconv.AppendQuote(buf, s)
+	}
+
+	return append(buf, s...)
+}
+
+// needsQuoting reports whether s can not be written without quotes
+// in a key=value pair.
+func needsQuoting(s string) bool {
+	if len(s) == 0 {
+		return true
+	}
+
+	for i := 0; i < len(s); {
+		b := s[i]
+		if b < utf8.RuneSelf {
+			if b <= ' ' || b == '=' || b == '"' || b == 0x7f {
+				return true
+			}
+
+			i++
+
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError || unicode.IsSpace(r) || !unicode.IsPrint(r) {
+			return true
+		}
+
+		i += size
+	}
+
+	return false
+}
+
+// appendPlainValue writes v as is, for the formats with their own escaping.
+func appendPlainValue(buf []byte, v slog.Value) []byte {
+	if err, ok := AsError(v); ok {
+		return append(buf, err.Error()...)
+	}
+
+	switch v.Kind() {
+	case slog.KindString:
+		return append(buf, v.String()...)
+	case slog.KindAny:
+		switch a := v.Any().(type) {
+		case FormattedValue:
+			return append(buf, a.String()...)
+		case encoding.TextMarshaler:
+			data, err := a.MarshalText()
+			if err != nil {
+				return append(buf, "!ERROR:"+err.Error()...)
+			}
+
+			return append(buf, data...)
+		case []byte:
+			return append(buf, a...)
+		default:
+			return fmt.Appendf(buf, "%+v", a)
+		}
+	default:
+		return appendTextValue(buf, v)
+	}
+}
+
+// flatAttrs calls fn with the attributes from WithAttrs and the record,
+// after ReplaceAttr, with the groups in place of the nesting. Empty
+// attrs and groups are elided.
+func (s *handleState) flatAttrs(fn func(groups []string, a slog.Attr)) {
+	for _, ga := range s.h.attrs {
+		for _, a := range ga.resolved {
+			s.flatAttr(ga.groups, a, fn)
+		}
+	}
+
+	for _, a := range *s.attrs {
+		s.flatAttr(s.h.groups, a, fn)
+	}
+}
+
+func (s *handleState) flatAttr(groups []string, a slog.Attr, fn func(groups []string, a slog.Attr)) {
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+
+	if a.Value.Kind() != slog.KindGroup {
+		fn(groups, a)
+
+		return
+	}
+
+	if a.Key != "" {
+		groups = append(slices.Clip(groups), a.Key)
+	}
+
+	for _, ga := range a.Value.Group() {
+		s.flatAttr(groups, ga, fn)
+	}
+}
@@ -0,0 +1,131 @@
+package unilogger
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+)
+
+type formatKind uint8
+
+const (
+	formatRaw formatKind = iota + 1
+	formatHex
+	formatQuoted
+	formatJSON
+	formatBase64
+)
+
+// Raw returns an attribute whose value is printed as a Go literal, like %#v.
+func Raw(key string, v any) slog.Attr {
+	return slog.Any(key, FormattedValue{kind: formatRaw, v: v})
+}
+
+// Hex returns an attribute whose value is printed in hexadecimal.
+// Bytes and strings are written as hex digits, numbers with the 0x prefix.
+func Hex(key string, v any) slog.Attr {
+	return slog.Any(key, FormattedValue{kind: formatHex, v: v})
+}
+
+// Quoted returns an attribute whose value is printed as a Go quoted string,
+// so that invisible characters are seen in the output.
+func Quoted(key string, v any) slog.Attr {
+	return slog.Any(key, FormattedValue{kind: formatQuoted, v: v})
+}
+
+// JSON returns an attribute embedded as is into the JSON records
+// and written compacted in the text formats. Invalid JSON is written as a string.
+func JSON(key string, msg json.RawMessage) slog.Attr {
+	return slog.Any(key, FormattedValue{kind: formatJSON, v: msg})
+}
+
+// Base64 returns an attribute whose value is printed in the standard base64 encoding.
+func Base64(key string, b []byte) slog.Attr {
+	return slog.Any(key, FormattedValue{kind: formatBase64, v: b})
+}
+
+// FormattedValue is the value of Raw, Hex, Quoted, JSON and Base64 attributes.
+// It implements json.Marshaler and encoding.TextMarshaler,
+// so the stdlib handlers render it the same way.
+type FormattedValue struct {
+	kind formatKind
+	v    any
+}
+
+// String returns the value as written in the text formats.
+func (f FormattedValue) String() string {
+	switch f.kind {
+	case formatRaw:
+		return fmt.Sprintf("%#v", f.v)
+	case formatHex:
+		switch f.v.(type) {
+		case []byte, string:
+			return fmt.Sprintf("%x", f.v)
+		default:
+			return fmt.Sprintf("%#x", f.v)
+		}
+	case formatQuoted:
+		if s, ok := f.v.(string); ok {
+			return strconv.Quote(s)
+		}
+
+		return strconv.Quote(fmt.Sprint(f.v))
+	case formatJSON:
+		msg, _ := f.json()
+
+		return string(msg)
+	case formatBase64:
+		b, _ := f.v.([]byte)
+
+		return base64.StdEncoding.EncodeToString(b)
+	default:
+		return fmt.Sprint(f.v)
+	}
+}
+
+// IsJSON reports whether the value is valid JSON to be embedded as is.
+func (f FormattedValue) IsJSON() bool {
+	_, ok := f.json()
+
+	return ok
+}
+
+// json returns the compacted JSON of a JSON attribute.
+func (f FormattedValue) json() ([]byte, bool) {
+	msg, _ := f.v.(json.RawMessage)
+
+	var bb bytes.Buffer
+	if f.kind != formatJSON || json.Compact(&bb, msg) != nil {
+		return msg, false
+	}
+
+	return bb.Bytes(), true
+}
+
+func (f FormattedValue) MarshalJSON() ([]byte, error) {
+	return f.appendJSON(nil), nil
+}
+
+func (f FormattedValue) MarshalText() ([]byte, error) {
+	return []byte(f.String()), nil
+}
+
+func (f FormattedValue) appendJSON(buf []byte) []byte {
+	if msg, ok := f.json(); ok {
+		return append(buf, msg...)
+	}
+
+	return appendJSONString(buf, f.String())
+}
+
+func (f FormattedValue) appendText(buf []byte) []byte {
+	// already quoted
+	if f.kind == formatQuoted {
+		return append(buf, f.String()...)
+	}
+
+	return appendTextString(buf, f.String())
+}
@@ -0,0 +1,157 @@
+package unilogger_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	uberzap "go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"slog-test/unilogger"
+	"slog-test/zap"
+)
+
+type stubPoint struct {
+	X, Y int
+}
+
+func Test_FormattedValues(t *testing.T) {
+	t.Parallel()
+
+	type meta struct {
+		name    string
+		enabled bool
+	}
+
+	type args struct {
+		attr slog.Attr
+	}
+
+	type wants struct {
+		json   string
+		logfmt string
+	}
+
+	tests := []struct {
+		meta  meta
+		args  args
+		wants wants
+	}{
+		{
+			meta: meta{
+				name:    "raw",
+				enabled: true,
+			},
+			args: args{attr: unilogger.Raw("v", stubPoint{X: 1, Y: 2})},
+			wants: wants{
+				json:   `"unilogger_test.stubPoint{X:1, Y:2}"`,
+				logfmt: `"unilogger_test.stubPoint{X:1, Y:2}"`,
+			},
+		},
+		{
+			meta: meta{
+				name:    "hex bytes",
+				enabled: true,
+			},
+			args: args{attr: unilogger.Hex("v", []byte{0xde, 0xad, 0xbe, 0xef})},
+			wants: wants{
+				json:   `"deadbeef"`,
+				logfmt: `deadbeef`,
+			},
+		},
+		{
+			meta: meta{
+				name:    "hex number",
+				enabled: true,
+			},
+			args: args{attr: unilogger.Hex("v", 255)},
+			wants: wants{
+				json:   `"0xff"`,
+				logfmt: `0xff`,
+			},
+		},
+		{
+			meta: meta{
+				name:    "quoted",
+				enabled: true,
+			},
+			args: args{attr: unilogger.Quoted("v", "a\tb")},
+			wants: wants{
+				json:   `"\"a\\tb\""`,
+				logfmt: `"a\tb"`,
+			},
+		},
+		{
+			meta: meta{
+				name:    "json is embedded compacted",
+				enabled: true,
+			},
+			args: args{attr: unilogger.JSON("v", json.RawMessage("{\"a\": [1, 2],\n\"b\": null}"))},
+			wants: wants{
+				json:   `{"a":[1,2],"b":null}`,
+				logfmt: `"{\"a\":[1,2],\"b\":null}"`,
+			},
+		},
+		{
+			meta: meta{
+				name:    "invalid json is a string",
+				enabled: true,
+			},
+			args: args{attr: unilogger.JSON("v", json.RawMessage(`{"a":`))},
+			wants: wants{
+				json:   `"{\"a\":"`,
+				logfmt: `"{\"a\":"`,
+			},
+		},
+		{
+			meta: meta{
+				name:    "base64",
+				enabled: true,
+			},
+			args: args{attr: unilogger.Base64("v", []byte("hello?"))},
+			wants: wants{
+				json:   `"aGVsbG8/"`,
+				logfmt: `aGVsbG8/`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		if !tt.meta.enabled {
+			continue
+		}
+
+		t.Run(tt.meta.name, func(t *testing.T) {
+			var (
+				jsonBuf   = bytes.NewBuffer([]byte{})
+				logfmtBuf = bytes.NewBuffer([]byte{})
+				stdBuf    = bytes.NewBuffer([]byte{})
+				zapBuf    = bytes.NewBuffer([]byte{})
+			)
+
+			unilogger.NewLogger(unilogger.Options{Output: jsonBuf, TimeFunc: stubTimeFn}).
+				Info("stub msg", tt.args.attr)
+			unilogger.NewLogger(unilogger.Options{Output: logfmtBuf, TimeFunc: stubTimeFn, Format: unilogger.FormatLogfmt}).
+				Info("stub msg", tt.args.attr)
+
+			assert.Equal(t, `{"level":"info","msg":"stub msg","v":`+tt.wants.json+`,"time":"2006-01-02T15:04:05Z"}`+"\n", jsonBuf.String())
+			assert.Equal(t, `level=info msg="stub msg" v=`+tt.wants.logfmt+` time=2006-01-02T15:04:05Z`+"\n", logfmtBuf.String())
+
+			// other backends render the value the same way
+			slog.New(slog.NewJSONHandler(stdBuf, nil)).Info("stub msg", tt.args.attr)
+
+			core := zapcore.NewCore(zapcore.NewJSONEncoder(zapcore.EncoderConfig{}), zapcore.AddSync(zapBuf), zapcore.InfoLevel)
+			slog.New(zap.NewZapHandler(uberzap.New(core))).Info("stub msg", tt.args.attr)
+
+			for _, out := range []*bytes.Buffer{stdBuf, zapBuf} {
+				var record map[string]json.RawMessage
+
+				assert.NoError(t, json.Unmarshal(out.Bytes(), &record))
+				assert.Equal(t, tt.wants.json, string(record["v"]))
+			}
+		})
+	}
+}
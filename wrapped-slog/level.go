@@ -1,47 +1,15 @@
 package wrappedslog
 
-import (
-	"fmt"
-	"log/slog"
-)
-
-var _ slog.Leveler = (*Level)(nil)
+import "slog-test/levels"
 
-type Level slog.Level
+type Level = levels.Level
 
 const (
-	LevelTrace Level = -8
-	LevelDebug Level = -4
-	LevelInfo  Level = 0
-	LevelWarn  Level = 4
-	LevelError Level = 8
-	LevelFatal Level = 12
+	LevelTrace = levels.LevelTrace
+	LevelDebug = levels.LevelDebug
+	LevelInfo  = levels.LevelInfo
+	LevelWarn  = levels.LevelWarn
+	LevelError = levels.LevelError
+	LevelPanic = levels.LevelPanic
+	LevelFatal = levels.LevelFatal
 )
-
-func (l Level) Level() slog.Level {
-	return slog.Level(l)
-}
-
-func (l Level) String() string {
-	str := func(base string, val Level) string {
-		if val == 0 {
-			return base
-		}
-		return fmt.Sprintf("%s%+d", base, val)
-	}
-
-	switch {
-	case l < LevelDebug:
-		return str("trace", l-LevelTrace)
-	case l < LevelInfo:
-		return str("debug", l-LevelDebug)
-	case l < LevelWarn:
-		return str("info", l-LevelInfo)
-	case l < LevelError:
-		return str("warn", l-LevelWarn)
-	case l < LevelFatal:
-		return str("error", l-LevelWarn)
-	default:
-		return str("fatal", l-LevelFatal)
-	}
-}
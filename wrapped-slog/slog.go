@@ -7,6 +7,8 @@ import (
 	"log/slog"
 	"runtime"
 	"time"
+
+	"slog-test/unilogger"
 )
 
 type logger = slog.Logger
@@ -22,6 +24,7 @@ type HandlerType int
 const (
 	JSONHandler HandlerType = iota
 	TextHandler
+	LogfmtHandler
 )
 
 func New(w io.Writer, ht HandlerType, level Level, addSource bool) *Logger {
@@ -36,6 +39,8 @@ func New(w io.Writer, ht HandlerType, level Level, addSource bool) *Logger {
 		logger.logger = slog.New(slog.NewJSONHandler(w, logger.opts))
 	case TextHandler:
 		logger.logger = slog.New(slog.NewTextHandler(w, logger.opts))
+	case LogfmtHandler:
+		logger.logger = slog.New(unilogger.NewLogfmtHandler(w, logger.opts, nil))
 	}
 
 	return logger
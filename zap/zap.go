@@ -6,6 +6,9 @@ import (
 
 	uberzap "go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+
+	"slog-test/levels"
+	"slog-test/unilogger"
 )
 
 // Extends default slog with new log levels
@@ -21,7 +24,7 @@ func NewSlogLogger(h slog.Handler) *WrappedLogger {
 
 // New LogLevels
 const (
-	LevelFatal = slog.Level(12)
+	LevelFatal = slog.Level(levels.LevelFatal)
 )
 
 // Fatal logs at LevelFatal.
@@ -59,24 +62,28 @@ func NewZapHandler(logger *uberzap.Logger) *ZapHandler {
 	}
 }
 
-func (h *ZapHandler) Enabled(_ context.Context, level slog.Level) bool {
-	var zapLevel zapcore.Level
-	switch level {
-	case slog.LevelDebug:
-		zapLevel = zapcore.DebugLevel
-	case slog.LevelInfo:
-		zapLevel = zapcore.InfoLevel
-	case slog.LevelWarn:
-		zapLevel = zapcore.WarnLevel
-	case slog.LevelError:
-		zapLevel = zapcore.ErrorLevel
-	case LevelFatal:
-		zapLevel = zapcore.FatalLevel
+// ZapLevel maps the level to the zap level it falls into, so the registered
+// levels go with the built-in level below them. Trace goes to debug,
+// the lowest zap level.
+func ZapLevel(level slog.Level) zapcore.Level {
+	switch l := levels.Level(level); {
+	case l < levels.LevelInfo:
+		return zapcore.DebugLevel
+	case l < levels.LevelWarn:
+		return zapcore.InfoLevel
+	case l < levels.LevelError:
+		return zapcore.WarnLevel
+	case l < levels.LevelPanic:
+		return zapcore.ErrorLevel
+	case l < levels.LevelFatal:
+		return zapcore.PanicLevel
 	default:
-		zapLevel = zapcore.ErrorLevel // to catch default
+		return zapcore.FatalLevel
 	}
+}
 
-	return h.logger.Core().Enabled(zapLevel)
+func (h *ZapHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.logger.Core().Enabled(ZapLevel(level))
 }
 
 func (h *ZapHandler) Handle(_ context.Context, rec slog.Record) error {
@@ -90,20 +97,8 @@ func (h *ZapHandler) Handle(_ context.Context, rec slog.Record) error {
 
 	entry := h.logger.With(fields...)
 
-	switch rec.Level {
-	case slog.LevelDebug:
-		entry.Debug(rec.Message)
-	case slog.LevelInfo.Level():
-		entry.Info(rec.Message)
-	case slog.LevelWarn:
-		entry.Warn(rec.Message)
-	case slog.LevelError:
-		entry.Error(rec.Message)
-	case LevelFatal:
-		entry.Fatal(rec.Message)
-	default:
-		entry.Error(rec.Message) // to catch default
-	}
+	// panic and fatal levels panic and exit after writing
+	entry.Log(ZapLevel(rec.Level), rec.Message)
 
 	return nil
 }
@@ -127,6 +122,18 @@ func (h *ZapHandler) WithGroup(name string) slog.Handler {
 }
 
 func SlogAttToZapField(a slog.Attr) uberzap.Field {
+	if err, ok := unilogger.AsError(a.Value); ok {
+		return uberzap.Object(a.Key, zapError(unilogger.DescribeError(err)))
+	}
+
+	if f, ok := a.Value.Any().(unilogger.FormattedValue); ok {
+		if f.IsJSON() {
+			return uberzap.Reflect(a.Key, f)
+		}
+
+		return uberzap.String(a.Key, f.String())
+	}
+
 	switch a.Value.Kind() {
 	case slog.KindBool:
 		return uberzap.Bool(a.Key, a.Value.Bool())
@@ -146,3 +153,47 @@ func SlogAttToZapField(a slog.Attr) uberzap.Field {
 		return uberzap.Any(a.Key, a.Value.Any())
 	}
 }
+
+// zapError renders the error the same way as unilogger handlers do.
+type zapError unilogger.ErrorInfo
+
+func (e zapError) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("msg", e.Msg)
+	enc.AddString("type", e.Type)
+
+	if len(e.Causes) > 0 {
+		err := enc.AddArray("causes", zapcore.ArrayMarshalerFunc(func(arr zapcore.ArrayEncoder) error {
+			for _, cause := range e.Causes {
+				if err := arr.AppendObject(zapError(cause)); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		}))
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(e.Stack) > 0 {
+		return enc.AddArray("stack", zapcore.ArrayMarshalerFunc(func(arr zapcore.ArrayEncoder) error {
+			for _, f := range e.Stack {
+				err := arr.AppendObject(zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+					enc.AddString("function", f.Function)
+					enc.AddString("file", f.File)
+					enc.AddInt("line", f.Line)
+
+					return nil
+				}))
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		}))
+	}
+
+	return nil
+}
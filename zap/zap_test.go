@@ -0,0 +1,53 @@
+package zap_test
+
+import (
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"go.uber.org/zap/zapcore"
+
+	"slog-test/levels"
+	"slog-test/zap"
+)
+
+func Test_ZapLevel(t *testing.T) {
+	const levelNotice = levels.LevelInfo + 2
+
+	assert.NoError(t, levels.Register("notice", levelNotice))
+
+	type meta struct {
+		name    string
+		enabled bool
+	}
+
+	type args struct {
+		level levels.Level
+	}
+
+	type wants struct {
+		level zapcore.Level
+	}
+
+	tests := []struct {
+		meta  meta
+		args  args
+		wants wants
+	}{
+		{meta: meta{name: "trace", enabled: true}, args: args{level: levels.LevelTrace}, wants: wants{level: zapcore.DebugLevel}},
+		{meta: meta{name: "info", enabled: true}, args: args{level: levels.LevelInfo}, wants: wants{level: zapcore.InfoLevel}},
+		{meta: meta{name: "registered notice", enabled: true}, args: args{level: levelNotice}, wants: wants{level: zapcore.InfoLevel}},
+		{meta: meta{name: "error offset", enabled: true}, args: args{level: levels.LevelError + 2}, wants: wants{level: zapcore.ErrorLevel}},
+		{meta: meta{name: "panic", enabled: true}, args: args{level: levels.LevelPanic}, wants: wants{level: zapcore.PanicLevel}},
+		{meta: meta{name: "fatal", enabled: true}, args: args{level: levels.LevelFatal}, wants: wants{level: zapcore.FatalLevel}},
+	}
+
+	for _, tt := range tests {
+		if !tt.meta.enabled {
+			continue
+		}
+
+		t.Run(tt.meta.name, func(t *testing.T) {
+			assert.Equal(t, tt.wants.level, zap.ZapLevel(tt.args.level.Level()))
+		})
+	}
+}